@@ -0,0 +1,126 @@
+package redisobj
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cupen/redisobj/encoders"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrEncoderMismatch  = errors.New("encoder mismatch")
+	ErrEncodedAggregate = errors.New("encoded scores can only be aggregated by MIN or MAX without weights")
+)
+
+type Aggregate string
+
+const (
+	AggregateSum Aggregate = "SUM"
+	AggregateMin Aggregate = "MIN"
+	AggregateMax Aggregate = "MAX"
+)
+
+// run ZUNIONSTORE or ZINTERSTORE with an optional ttl on dst in one transaction
+func zstore(rds *redis.Client, union bool, dst string, keys []string, weights []float64, aggregate Aggregate, ttl time.Duration) (int64, error) {
+	if len(weights) > 0 && len(weights) != len(keys) {
+		return 0, fmt.Errorf("invalid params: %d weights for %d keys", len(weights), len(keys))
+	}
+	store := &redis.ZStore{
+		Keys:      keys,
+		Weights:   weights,
+		Aggregate: string(aggregate),
+	}
+	c := context.TODO()
+	var cmd *redis.IntCmd
+	_, err := rds.TxPipelined(c, func(pipe redis.Pipeliner) error {
+		if union {
+			cmd = pipe.ZUnionStore(c, dst, store)
+		} else {
+			cmd = pipe.ZInterStore(c, dst, store)
+		}
+		if ttl > 0 {
+			pipe.Expire(c, dst, ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cmd.Val(), nil
+}
+
+func zsetKeys(this *ZSet, sources []*ZSet) []string {
+	keys := make([]string, 0, len(sources)+1)
+	keys = append(keys, this.key)
+	for _, s := range sources {
+		keys = append(keys, s.key)
+	}
+	return keys
+}
+
+// UnionInto stores the union of this and sources into dst, returns the size of dst.
+// weights are for this and sources in order, empty means all 1. ttl <= 0 means no TTL.
+func (this *ZSet) UnionInto(dst *ZSet, sources []*ZSet, weights []float64, aggregate Aggregate, ttl time.Duration) (int64, error) {
+	return zstore(this.redis, true, dst.key, zsetKeys(this, sources), weights, aggregate, ttl)
+}
+
+// IntersectInto stores the intersection of this and sources into dst, returns the size of dst.
+// weights are for this and sources in order, empty means all 1. ttl <= 0 means no TTL.
+func (this *ZSet) IntersectInto(dst *ZSet, sources []*ZSet, weights []float64, aggregate Aggregate, ttl time.Duration) (int64, error) {
+	return zstore(this.redis, false, dst.key, zsetKeys(this, sources), weights, aggregate, ttl)
+}
+
+// all the ranklists must use the same encoder, and encoded scores can't be summed or weighted
+// since the factor bits would overflow into the score.
+func (this *RankList) _storeKeys(dst *RankList, sources []*RankList, weights []float64, aggregate Aggregate) ([]string, error) {
+	keys := make([]string, 0, len(sources)+1)
+	keys = append(keys, this.key)
+	for _, s := range append([]*RankList{this, dst}, sources...) {
+		if err := s.CheckEncoder(); err != nil {
+			return nil, err
+		}
+		if !encoders.Equal(s._encoder(), this._encoder()) {
+			return nil, fmt.Errorf("%w: %s and %s", ErrEncoderMismatch, this.key, s.key)
+		}
+	}
+	for _, s := range sources {
+		keys = append(keys, s.key)
+	}
+	if this._encoder() == nil {
+		return keys, nil
+	}
+	if aggregate != AggregateMin && aggregate != AggregateMax {
+		return nil, ErrEncodedAggregate
+	}
+	for _, w := range weights {
+		if w != 1 {
+			return nil, ErrEncodedAggregate
+		}
+	}
+	return keys, nil
+}
+
+// UnionInto stores the union of this and sources into dst, returns the size of dst.
+// weights are for this and sources in order, empty means all 1. ttl <= 0 means no TTL.
+// With an encoder, all ranklists must use the same one and only MIN/MAX without weights is allowed.
+func (this *RankList) UnionInto(dst *RankList, sources []*RankList, weights []float64, aggregate Aggregate, ttl time.Duration) (int64, error) {
+	keys, err := this._storeKeys(dst, sources, weights, aggregate)
+	if err != nil {
+		return 0, err
+	}
+	return zstore(this.redis, true, dst.key, keys, weights, aggregate, ttl)
+}
+
+// IntersectInto stores the intersection of this and sources into dst, returns the size of dst.
+// weights are for this and sources in order, empty means all 1. ttl <= 0 means no TTL.
+// With an encoder, all ranklists must use the same one and only MIN/MAX without weights is allowed.
+func (this *RankList) IntersectInto(dst *RankList, sources []*RankList, weights []float64, aggregate Aggregate, ttl time.Duration) (int64, error) {
+	keys, err := this._storeKeys(dst, sources, weights, aggregate)
+	if err != nil {
+		return 0, err
+	}
+	return zstore(this.redis, false, dst.key, keys, weights, aggregate, ttl)
+}
@@ -100,3 +100,42 @@ func (this *Countor) SetTTLAt(ts time.Time) error {
 	c := context.TODO()
 	return this.redis.ExpireAt(c, this.key, ts).Err()
 }
+
+// GetOrZero is like Get but returns 0 instead of redis.Nil for a missing key.
+func (this *Countor) GetOrZero() (int64, error) {
+	rs, err := this.Get64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return rs, err
+}
+
+func (this *Countor) Inc64() (int64, error) {
+	c := context.TODO()
+	return this.redis.Incr(c, this.key).Result()
+}
+
+func (this *Countor) IncBy64(inc int64) (int64, error) {
+	c := context.TODO()
+	return this.redis.IncrBy(c, this.key, inc).Result()
+}
+
+func (this *Countor) Dec64() (int64, error) {
+	c := context.TODO()
+	return this.redis.Decr(c, this.key).Result()
+}
+
+func (this *Countor) DecBy64(dec int64) (int64, error) {
+	c := context.TODO()
+	return this.redis.DecrBy(c, this.key, dec).Result()
+}
+
+func (this *Countor) Set64(val int64) error {
+	c := context.TODO()
+	return this.redis.Set(c, this.key, val, 0).Err()
+}
+
+func (this *Countor) Get64() (int64, error) {
+	c := context.TODO()
+	return this.redis.Get(c, this.key).Int64()
+}
@@ -0,0 +1,83 @@
+package redisobj
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// FloatCountor is a counter with fractional amounts, based on INCRBYFLOAT.
+type FloatCountor core
+
+func NewFloatCountor(redis *redis.Client, key string) FloatCountor {
+	return FloatCountor{
+		redis: redis,
+		key:   key,
+	}
+}
+
+func (this *FloatCountor) IncBy(inc float64) (float64, error) {
+	c := context.TODO()
+	return this.redis.IncrByFloat(c, this.key, inc).Result()
+}
+
+func (this *FloatCountor) DecBy(dec float64) (float64, error) {
+	return this.IncBy(-dec)
+}
+
+func (this *FloatCountor) IncWithTTL(inc float64, ttl time.Duration) (float64, error) {
+	v, err := this.IncBy(inc)
+	this.SetTTL(ttl)
+	return v, err
+}
+
+func (this *FloatCountor) Set(val float64) error {
+	c := context.TODO()
+	return this.redis.Set(c, this.key, val, 0).Err()
+}
+
+// setnx with ttl
+func (this *FloatCountor) InitOnce(val float64, ttl time.Duration) error {
+	c := context.TODO()
+	return this.redis.SetNX(c, this.key, val, ttl).Err()
+}
+
+func (this *FloatCountor) SetWithTTL(val float64, ttl time.Duration) error {
+	c := context.TODO()
+	return this.redis.Set(c, this.key, val, ttl).Err()
+}
+
+func (this *FloatCountor) Get() (float64, error) {
+	c := context.TODO()
+	return this.redis.Get(c, this.key).Float64()
+}
+
+// GetOrZero is like Get but returns 0 instead of redis.Nil for a missing key.
+func (this *FloatCountor) GetOrZero() (float64, error) {
+	rs, err := this.Get()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return rs, err
+}
+
+func (this *FloatCountor) Reset() {
+	c := context.TODO()
+	this.redis.Del(c, this.key)
+}
+
+func (this *FloatCountor) SetTTL(ttl time.Duration) {
+	c := context.TODO()
+	this.redis.Expire(c, this.key, ttl)
+}
+
+func (this *FloatCountor) GetTTL() (time.Duration, error) {
+	c := context.TODO()
+	return this.redis.TTL(c, this.key).Result()
+}
+
+func (this *FloatCountor) SetTTLAt(ts time.Time) error {
+	c := context.TODO()
+	return this.redis.ExpireAt(c, this.key, ts).Err()
+}
@@ -0,0 +1,181 @@
+package redisobj
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ShardedCountor spreads the writes of a hot counter across N sub-keys ("key:0" ... "key:N-1").
+type ShardedCountor struct {
+	core
+	shards int
+}
+
+func NewShardedCountor(redis *redis.Client, key string, shards int) ShardedCountor {
+	if shards <= 0 {
+		panic(fmt.Errorf("invalid shards: %d", shards))
+	}
+	return ShardedCountor{
+		core:   *newCore(redis, key),
+		shards: shards,
+	}
+}
+
+func (this *ShardedCountor) Shards() int {
+	return this.shards
+}
+
+func (this *ShardedCountor) shardKey(i int) string {
+	return this.buildKey(strconv.Itoa(i))
+}
+
+func (this *ShardedCountor) shardKeys() []string {
+	keys := make([]string, this.shards)
+	for i := range keys {
+		keys[i] = this.shardKey(i)
+	}
+	return keys
+}
+
+func (this *ShardedCountor) shardOf(hint string) int {
+	h := fnv.New32a()
+	h.Write([]byte(hint))
+	return int(h.Sum32() % uint32(this.shards))
+}
+
+// Inc increments a random shard and returns the value of that shard, see Get for the total.
+func (this *ShardedCountor) Inc() (int, error) {
+	return this.IncBy(1)
+}
+
+// IncBy increments a random shard by inc and returns the value of that shard.
+func (this *ShardedCountor) IncBy(inc int) (int, error) {
+	c := context.TODO()
+	key := this.shardKey(rand.Intn(this.shards))
+	rs, err := this.redis.IncrBy(c, key, int64(inc)).Result()
+	return int(rs), err
+}
+
+// IncByHint increments the shard picked by hashing hint, e.g. a user id, and returns the value of that shard.
+func (this *ShardedCountor) IncByHint(hint string, inc int) (int, error) {
+	c := context.TODO()
+	key := this.shardKey(this.shardOf(hint))
+	rs, err := this.redis.IncrBy(c, key, int64(inc)).Result()
+	return int(rs), err
+}
+
+func (this *ShardedCountor) Dec() (int, error) {
+	return this.IncBy(-1)
+}
+
+func (this *ShardedCountor) DecBy(dec int) (int, error) {
+	return this.IncBy(-dec)
+}
+
+// Get returns the sum of all shards read by one GET per shard in a pipeline, missing shards count as 0.
+// The shards are read one by one, so the sum is not a snapshot of a single instant.
+func (this *ShardedCountor) Get() (int, error) {
+	c := context.TODO()
+	keys := this.shardKeys()
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(c, key)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	total := 0
+	for i, cmd := range cmds {
+		s, err := cmd.Result()
+		if err != nil {
+			if err == redis.Nil {
+				continue
+			}
+			return 0, err
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid shard value: %s=%s", keys[i], s)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (this *ShardedCountor) Reset() {
+	c := context.TODO()
+	this.redis.Unlink(c, this.shardKeys()...)
+}
+
+func (this *ShardedCountor) SetTTL(ttl time.Duration) {
+	c := context.TODO()
+	this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for _, key := range this.shardKeys() {
+			pipe.Expire(c, key, ttl)
+		}
+		return nil
+	})
+}
+
+func (this *ShardedCountor) SetTTLAt(ts time.Time) error {
+	c := context.TODO()
+	_, err := this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for _, key := range this.shardKeys() {
+			pipe.ExpireAt(c, key, ts)
+		}
+		return nil
+	})
+	return err
+}
+
+// fold the other shards into the first one, KEYS: shards; returns the value of the first shard.
+// A shard is deleted only after its value is added, so an invalid value fails the script without loss.
+var luaShardedCountorCompact = redis.NewScript(`
+for i = 2, #KEYS do
+	local v = redis.call("GET", KEYS[i])
+	if v then
+		redis.call("INCRBY", KEYS[1], v)
+		redis.call("DEL", KEYS[i])
+	end
+end
+return redis.call("GET", KEYS[1])
+`)
+
+// Compact folds the other shards into the first one atomically and returns the value of the first shard.
+// Note: the TTL of the folded shards is lost, call SetTTL again if needed.
+func (this *ShardedCountor) Compact() (int, error) {
+	c := context.TODO()
+	rs, err := luaShardedCountorCompact.Run(c, this.redis, this.shardKeys()).Text()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return strconv.Atoi(rs)
+}
+
+// StartCompaction runs Compact every interval until ctx is done.
+func (this *ShardedCountor) StartCompaction(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, _ = this.Compact()
+			}
+		}
+	}()
+}
@@ -0,0 +1,167 @@
+package redisobj
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient() *redis.Client {
+	url := "redis://127.0.0.1:6379/15"
+	opt, _ := redis.ParseURL(url)
+	return redis.NewClient(opt)
+}
+
+func TestShardedCountor_Compact(t *testing.T) {
+	counter := NewShardedCountor(newTestClient(), "prefiex_test_ShardedCountor", 4)
+	counter.Reset()
+	t.Cleanup(func() {
+		counter.Reset()
+	})
+
+	for i := 0; i < 100; i++ {
+		_, err := counter.IncByHint(fmt.Sprintf("user%d", i), 2)
+		assert.NoError(t, err)
+	}
+	n, err := counter.Dec()
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, n, 199)
+	total, err := counter.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, 199, total)
+
+	first, err := counter.Compact()
+	assert.NoError(t, err)
+	assert.Equal(t, 199, first)
+	c := context.TODO()
+	keys := counter.shardKeys()
+	exists, err := counter.redis.Exists(c, keys[1:]...).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), exists)
+
+	total, err = counter.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, 199, total)
+
+	// a compacted counter keeps counting on every shard
+	counter.IncBy(1)
+	total, err = counter.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, 200, total)
+}
+
+func TestCountor_Int64(t *testing.T) {
+	counter := NewCountor(newTestClient(), "prefiex_test_Countor_int64")
+	counter.Reset()
+	t.Cleanup(func() {
+		counter.Reset()
+	})
+
+	n, err := counter.GetOrZero()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	big := int64(math.MaxInt32) * 4
+	assert.NoError(t, counter.Set64(big))
+	n, err = counter.IncBy64(big)
+	assert.NoError(t, err)
+	assert.Equal(t, big*2, n)
+	n, err = counter.DecBy64(big)
+	assert.NoError(t, err)
+	assert.Equal(t, big, n)
+	n, err = counter.Inc64()
+	assert.NoError(t, err)
+	assert.Equal(t, big+1, n)
+	n, err = counter.Get64()
+	assert.NoError(t, err)
+	assert.Equal(t, big+1, n)
+}
+
+func TestFloatCountor(t *testing.T) {
+	counter := NewFloatCountor(newTestClient(), "prefiex_test_FloatCountor")
+	counter.Reset()
+	t.Cleanup(func() {
+		counter.Reset()
+	})
+
+	v, err := counter.GetOrZero()
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), v)
+	_, err = counter.Get()
+	assert.Equal(t, redis.Nil, err)
+
+	v, err = counter.IncBy(1.5)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, v)
+	v, err = counter.DecBy(0.25)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.25, v)
+
+	assert.NoError(t, counter.InitOnce(10, 0))
+	v, err = counter.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, 1.25, v)
+
+	v, err = counter.IncWithTTL(0.5, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.75, v)
+	ttl, err := counter.GetTTL()
+	assert.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0))
+}
+
+func TestCountorWithSet_Acquire(t *testing.T) {
+	slots := NewCountorWithSet(newTestClient(), "prefiex_test_CountorWithSet_Acquire", 3, time.Minute)
+	slots.Reset()
+	t.Cleanup(func() {
+		slots.Reset()
+	})
+
+	var wg sync.WaitGroup
+	granted := make([]bool, 10)
+	for i := range granted {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := slots.Acquire(fmt.Sprintf("id%d", i))
+			assert.NoError(t, err)
+			granted[i] = ok
+		}(i)
+	}
+	wg.Wait()
+	holders := make([]string, 0, 3)
+	for i, ok := range granted {
+		if ok {
+			holders = append(holders, fmt.Sprintf("id%d", i))
+		}
+	}
+	assert.Equal(t, 3, len(holders))
+	size, err := slots.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, size)
+
+	// a holder is granted again at capacity, others are not
+	ok, err := slots.Acquire(holders[0])
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = slots.Acquire("other")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	released, err := slots.Release(holders[0])
+	assert.NoError(t, err)
+	assert.True(t, released)
+	ok, err = slots.Acquire("other")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ttl, err := slots.redis.TTL(context.TODO(), slots.key).Result()
+	assert.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0))
+}
@@ -7,6 +7,22 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// add the member only if there is a free slot, ARGV: member, max, ttl(ms)
+var luaCountorWithSetAcquire = redis.NewScript(`
+local max = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+if redis.call("SISMEMBER", KEYS[1], ARGV[1]) == 0 then
+	if max > 0 and redis.call("SCARD", KEYS[1]) >= max then
+		return 0
+	end
+	redis.call("SADD", KEYS[1], ARGV[1])
+end
+if ttl > 0 then
+	redis.call("PEXPIRE", KEYS[1], ttl)
+end
+return 1
+`)
+
 type CountorWithSet struct {
 	core
 	min int
@@ -36,6 +52,9 @@ func (this *CountorWithSet) Inc(elems ...interface{}) error {
 	if err != nil {
 		return err
 	}
+	if this.ttl > 0 {
+		this.SetTTL(this.ttl)
+	}
 	// log.Printf("key:%s inc:%#v", this.key, elems)
 	return nil
 	// size, err := this.redis.SCard(c, this.key).Result()
@@ -66,6 +85,28 @@ func (this *CountorWithSet) Dec(elems ...interface{}) error {
 	return nil
 }
 
+// Acquire adds the member only if the set has less than max members, and returns whether the slot
+// was granted. A member which already holds a slot is granted again. max <= 0 means unlimited.
+func (this *CountorWithSet) Acquire(member string) (bool, error) {
+	c := context.TODO()
+	keys := []string{this.key}
+	rs, err := luaCountorWithSetAcquire.Run(c, this.redis, keys, member, this.max, this.ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return rs > 0, nil
+}
+
+// Release frees the slot held by member.
+func (this *CountorWithSet) Release(member string) (bool, error) {
+	c := context.TODO()
+	count, err := this.redis.SRem(c, this.key, member).Result()
+	if err == redis.Nil {
+		err = nil
+	}
+	return count > 0, err
+}
+
 func (this *CountorWithSet) Get() (int, error) {
 	return this.Size()
 }
@@ -0,0 +1,112 @@
+package encoders
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// the integers a float64 holds exactly
+const MaxSafeBits = 53
+
+var (
+	ErrScoreOverflow  = errors.New("score overflow")
+	ErrFactorOverflow = errors.New("factor overflow")
+)
+
+type BitsConfig struct {
+	ScoreBits       int  `json:"score_bits"`         // including the sign if Signed
+	FactorBits      int  `json:"factor_bits"`        // ScoreBits + FactorBits <= 53
+	Signed          bool `json:"signed"`             // negative scores are offset by 1<<(ScoreBits-1)
+	FirstInIsBigger bool `json:"first_in_is_bigger"` // the smaller factor is bigger, e.g. the earlier timestamp wins
+}
+
+// Bits packs an int64 score and a factor into the 53 safe bits of a float64:
+// (score + offset) << FactorBits | factor.
+type Bits struct {
+	cfg       BitsConfig
+	offset    int64
+	maxScore  int64
+	minScore  int64
+	maxFactor int64
+}
+
+func NewBits(cfg BitsConfig) (*Bits, error) {
+	if cfg.ScoreBits <= 0 || cfg.FactorBits < 0 || cfg.ScoreBits+cfg.FactorBits > MaxSafeBits {
+		return nil, fmt.Errorf("invalid bits: score(%d) + factor(%d) must be in (0, %d]", cfg.ScoreBits, cfg.FactorBits, MaxSafeBits)
+	}
+	b := &Bits{
+		cfg:       cfg,
+		maxScore:  1<<cfg.ScoreBits - 1,
+		maxFactor: 1<<cfg.FactorBits - 1,
+	}
+	if cfg.Signed {
+		b.offset = 1 << (cfg.ScoreBits - 1)
+		b.minScore = -b.offset
+		b.maxScore = b.offset - 1
+	}
+	return b, nil
+}
+
+// MustBits is like NewBits but panics on error.
+func MustBits(cfg BitsConfig) *Bits {
+	b, err := NewBits(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func (b *Bits) Config() BitsConfig {
+	return b.cfg
+}
+
+func (b *Bits) ScoreRange() (int64, int64) {
+	return b.minScore, b.maxScore
+}
+
+func (b *Bits) MaxFactor() int64 {
+	return b.maxFactor
+}
+
+func (b *Bits) EncodeInt64(score int64, factor int64) (int64, error) {
+	if score < b.minScore || score > b.maxScore {
+		return 0, fmt.Errorf("%w: %d not in [%d, %d]", ErrScoreOverflow, score, b.minScore, b.maxScore)
+	}
+	if factor < 0 || factor > b.maxFactor {
+		return 0, fmt.Errorf("%w: %d not in [0, %d]", ErrFactorOverflow, factor, b.maxFactor)
+	}
+	if b.cfg.FirstInIsBigger {
+		factor = b.maxFactor - factor
+	}
+	return (score+b.offset)<<b.cfg.FactorBits | factor, nil
+}
+
+func (b *Bits) DecodeInt64(encoded int64) int64 {
+	return encoded>>b.cfg.FactorBits - b.offset
+}
+
+// Encode implements Score, it returns 0 if the score or the factor doesn't fit. Use EncodeInt64 to get the error.
+func (b *Bits) Encode(score int32, factor int32) int64 {
+	rs, err := b.EncodeInt64(int64(score), int64(factor))
+	if err != nil {
+		return 0
+	}
+	return rs
+}
+
+// Decode implements Score, the score is clamped to int32. Use DecodeInt64 for wider scores.
+func (b *Bits) Decode(score int64) int32 {
+	return clampInt32(b.DecodeInt64(score))
+}
+
+// the int32 closest to v, for the Decode of the int64 encoders
+func clampInt32(v int64) int32 {
+	if v > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	if v < math.MinInt32 {
+		return math.MinInt32
+	}
+	return int32(v)
+}
@@ -0,0 +1,64 @@
+package encoders
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBits(t *testing.T) {
+	t.Run("NewBits", func(t *testing.T) {
+		_, err := NewBits(BitsConfig{ScoreBits: 32, FactorBits: 22})
+		assert.Error(t, err)
+		_, err = NewBits(BitsConfig{ScoreBits: 0, FactorBits: 21})
+		assert.Error(t, err)
+		_, err = NewBits(BitsConfig{ScoreBits: 32, FactorBits: 21})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Encode/Decode", func(t *testing.T) {
+		enc := MustBits(BitsConfig{ScoreBits: 32, FactorBits: 21, Signed: true})
+		min, max := enc.ScoreRange()
+		require.Equal(t, int64(math.MinInt32), min)
+		require.Equal(t, int64(math.MaxInt32), max)
+
+		scores := []int64{min, min + 1, -1000, -1, 0, 1, 1000, max - 1, max}
+		var last int64 = -1
+		for _, score := range scores {
+			for _, factor := range []int64{0, 1, enc.MaxFactor()} {
+				name := fmt.Sprintf("score=%d,factor=%d", score, factor)
+				got, err := enc.EncodeInt64(score, factor)
+				require.NoError(t, err, name)
+				require.Greater(t, got, last, name)
+				require.Less(t, got, int64(1)<<MaxSafeBits, name)
+				require.Equal(t, score, enc.DecodeInt64(got), name)
+				last = got
+			}
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		enc := MustBits(BitsConfig{ScoreBits: 8, FactorBits: 4})
+		_, err := enc.EncodeInt64(-1, 0)
+		assert.ErrorIs(t, err, ErrScoreOverflow)
+		_, err = enc.EncodeInt64(256, 0)
+		assert.ErrorIs(t, err, ErrScoreOverflow)
+		_, err = enc.EncodeInt64(1, 16)
+		assert.ErrorIs(t, err, ErrFactorOverflow)
+		_, err = enc.EncodeInt64(1, -1)
+		assert.ErrorIs(t, err, ErrFactorOverflow)
+	})
+
+	t.Run("FirstInIsBigger", func(t *testing.T) {
+		enc := MustBits(BitsConfig{ScoreBits: 32, FactorBits: 21, FirstInIsBigger: true})
+		first, err := enc.EncodeInt64(10, 100)
+		require.NoError(t, err)
+		second, err := enc.EncodeInt64(10, 200)
+		require.NoError(t, err)
+		assert.Greater(t, first, second)
+		assert.Equal(t, int64(10), enc.DecodeInt64(second))
+	})
+}
@@ -0,0 +1,217 @@
+package encoders
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrFieldCount = errors.New("field count mismatch")
+)
+
+type Field struct {
+	Name    string `json:"name"`
+	Bits    int    `json:"bits"`    // including the sign if Signed
+	Signed  bool   `json:"signed"`  // negative values are offset by 1<<(Bits-1)
+	Reverse bool   `json:"reverse"` // the smaller value is bigger, e.g. the finish time
+}
+
+// Composite packs several fields into the 53 safe bits of a float64, the first field is the most significant.
+// As a Score64, the first field is the score and the other fields are the factor.
+type Composite struct {
+	fields []Field
+	shifts []int
+	bits   int
+}
+
+func NewComposite(fields ...Field) (*Composite, error) {
+	if len(fields) <= 0 {
+		return nil, fmt.Errorf("invalid composite: no fields")
+	}
+	c := &Composite{
+		fields: fields,
+		shifts: make([]int, len(fields)),
+	}
+	for i := len(fields) - 1; i >= 0; i-- {
+		f := fields[i]
+		if f.Bits <= 0 {
+			return nil, fmt.Errorf("invalid field \"%s\": bits(%d)", f.Name, f.Bits)
+		}
+		c.shifts[i] = c.bits
+		c.bits += f.Bits
+	}
+	if c.bits > MaxSafeBits {
+		return nil, fmt.Errorf("invalid composite: %d bits > %d", c.bits, MaxSafeBits)
+	}
+	return c, nil
+}
+
+// MustComposite is like NewComposite but panics on error.
+func MustComposite(fields ...Field) *Composite {
+	c, err := NewComposite(fields...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (c *Composite) Fields() []Field {
+	return c.fields
+}
+
+func (c *Composite) fieldRange(f Field) (int64, int64) {
+	if f.Signed {
+		return -(1 << (f.Bits - 1)), 1<<(f.Bits-1) - 1
+	}
+	return 0, 1<<f.Bits - 1
+}
+
+func (c *Composite) encodeField(f Field, v int64) (int64, error) {
+	min, max := c.fieldRange(f)
+	if v < min || v > max {
+		return 0, fmt.Errorf("%w: field \"%s\" %d not in [%d, %d]", ErrScoreOverflow, f.Name, v, min, max)
+	}
+	v -= min
+	if f.Reverse {
+		v = (max - min) - v
+	}
+	return v, nil
+}
+
+func (c *Composite) decodeField(f Field, v int64) int64 {
+	min, max := c.fieldRange(f)
+	v &= max - min
+	if f.Reverse {
+		v = (max - min) - v
+	}
+	return v + min
+}
+
+// EncodeFields packs the values in the order of fields.
+func (c *Composite) EncodeFields(values ...int64) (int64, error) {
+	if len(values) != len(c.fields) {
+		return 0, fmt.Errorf("%w: %d values for %d fields", ErrFieldCount, len(values), len(c.fields))
+	}
+	var rs int64
+	for i, f := range c.fields {
+		v, err := c.encodeField(f, values[i])
+		if err != nil {
+			return 0, err
+		}
+		rs |= v << c.shifts[i]
+	}
+	return rs, nil
+}
+
+// DecodeFields returns the values in the order of fields.
+func (c *Composite) DecodeFields(encoded int64) []int64 {
+	values := make([]int64, len(c.fields))
+	for i, f := range c.fields {
+		values[i] = c.decodeField(f, encoded>>c.shifts[i])
+	}
+	return values
+}
+
+// the struct field of each composite field, by the tag `rank:"name"` or the field name (case-insensitive)
+func (c *Composite) structFields(v reflect.Value) ([]reflect.Value, error) {
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("invalid type %s: not a struct", v.Type())
+	}
+	t := v.Type()
+	rs := make([]reflect.Value, len(c.fields))
+	for i, f := range c.fields {
+		for j := 0; j < t.NumField(); j++ {
+			sf := t.Field(j)
+			name := sf.Tag.Get("rank")
+			if name == "" {
+				name = sf.Name
+			}
+			if strings.EqualFold(name, f.Name) {
+				rs[i] = v.Field(j)
+				break
+			}
+		}
+		if !rs[i].IsValid() {
+			return nil, fmt.Errorf("invalid type %s: missing field \"%s\"", t, f.Name)
+		}
+		if !rs[i].CanInt() {
+			return nil, fmt.Errorf("invalid type %s: field \"%s\" is not a signed integer", t, f.Name)
+		}
+	}
+	return rs, nil
+}
+
+// EncodeStruct packs the integer fields of a struct (or a pointer to it).
+func (c *Composite) EncodeStruct(v interface{}) (int64, error) {
+	fields, err := c.structFields(reflect.ValueOf(v))
+	if err != nil {
+		return 0, err
+	}
+	values := make([]int64, len(fields))
+	for i, f := range fields {
+		values[i] = f.Int()
+	}
+	return c.EncodeFields(values...)
+}
+
+// DecodeStruct unpacks into the integer fields of the struct pointed by v.
+func (c *Composite) DecodeStruct(encoded int64, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("invalid type %T: not a pointer", v)
+	}
+	fields, err := c.structFields(rv)
+	if err != nil {
+		return err
+	}
+	for i, value := range c.DecodeFields(encoded) {
+		if fields[i].OverflowInt(value) {
+			return fmt.Errorf("%w: field \"%s\" %d overflows %s", ErrScoreOverflow, c.fields[i].Name, value, fields[i].Type())
+		}
+		fields[i].SetInt(value)
+	}
+	return nil
+}
+
+// EncodeInt64 implements Score64, the score is the first field and the factor is the other fields packed.
+func (c *Composite) EncodeInt64(score int64, factor int64) (int64, error) {
+	v, err := c.encodeField(c.fields[0], score)
+	if err != nil {
+		return 0, err
+	}
+	if factor < 0 || factor > c.MaxFactor() {
+		return 0, fmt.Errorf("%w: %d not in [0, %d]", ErrFactorOverflow, factor, c.MaxFactor())
+	}
+	return v<<c.shifts[0] | factor, nil
+}
+
+func (c *Composite) DecodeInt64(score int64) int64 {
+	return c.decodeField(c.fields[0], score>>c.shifts[0])
+}
+
+func (c *Composite) ScoreRange() (int64, int64) {
+	return c.fieldRange(c.fields[0])
+}
+
+func (c *Composite) MaxFactor() int64 {
+	return 1<<c.shifts[0] - 1
+}
+
+// Encode implements Score, it returns 0 if the score or the factor doesn't fit.
+func (c *Composite) Encode(score int32, factor int32) int64 {
+	rs, err := c.EncodeInt64(int64(score), int64(factor))
+	if err != nil {
+		return 0
+	}
+	return rs
+}
+
+// Decode implements Score, returns the first field clamped to int32.
+func (c *Composite) Decode(score int64) int32 {
+	return clampInt32(c.DecodeInt64(score))
+}
@@ -0,0 +1,66 @@
+package encoders
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposite(t *testing.T) {
+	enc := MustComposite(
+		Field{Name: "wins", Bits: 16},
+		Field{Name: "goals", Bits: 12, Signed: true},
+		Field{Name: "finish", Bits: 25, Reverse: true},
+	)
+
+	type result struct {
+		Wins     int32
+		GoalDiff int16 `rank:"goals"`
+		Finish   int64
+	}
+
+	t.Run("NewComposite", func(t *testing.T) {
+		_, err := NewComposite(Field{Name: "a", Bits: 50}, Field{Name: "b", Bits: 4})
+		assert.Error(t, err)
+		_, err = NewComposite()
+		assert.Error(t, err)
+	})
+
+	t.Run("Encode/Decode", func(t *testing.T) {
+		tests := []result{
+			{Wins: 3, GoalDiff: -5, Finish: 100},
+			{Wins: 3, GoalDiff: -5, Finish: 99},
+			{Wins: 3, GoalDiff: 2, Finish: 1000},
+			{Wins: 4, GoalDiff: -2048, Finish: 1<<25 - 1},
+		}
+		var last int64 = -1
+		for _, tt := range tests {
+			got, err := enc.EncodeStruct(tt)
+			require.NoError(t, err)
+			require.Greater(t, got, last)
+			require.Less(t, got, int64(1)<<MaxSafeBits)
+			last = got
+
+			var decoded result
+			require.NoError(t, enc.DecodeStruct(got, &decoded))
+			require.Equal(t, tt, decoded)
+			require.Equal(t, int64(tt.Wins), enc.DecodeInt64(got))
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		_, err := enc.EncodeFields(1, 2048, 0)
+		assert.ErrorIs(t, err, ErrScoreOverflow)
+		_, err = enc.EncodeFields(1, 2)
+		assert.ErrorIs(t, err, ErrFieldCount)
+		_, err = enc.EncodeStruct(struct{ Wins int }{1})
+		assert.Error(t, err)
+
+		wide := MustComposite(Field{Name: "wins", Bits: 40})
+		got, err := wide.EncodeFields(1 << 35)
+		require.NoError(t, err)
+		assert.Equal(t, int32(math.MaxInt32), wide.Decode(got))
+	})
+}
@@ -1,5 +1,7 @@
 package encoders
 
+import "time"
+
 var (
 	FirstInIsBigger = newFirstInIsBigger()
 	LastInIsBigger  = newLastInIsBigger()
@@ -9,3 +11,18 @@ type Score interface {
 	Encode(score int32, factor int32) int64
 	Decode(score int64) int32
 }
+
+// Score64 is a Score with int64 scores and overflow errors, the RankList prefers it if implemented.
+type Score64 interface {
+	Score
+	EncodeInt64(score int64, factor int64) (int64, error)
+	DecodeInt64(score int64) int64
+	ScoreRange() (min int64, max int64)
+	MaxFactor() int64
+}
+
+// TimeFactor is implemented by the encoders which take the factor from the write time,
+// RankList calls Factor when a write passes the factor 0.
+type TimeFactor interface {
+	Factor(now time.Time) (int32, error)
+}
@@ -0,0 +1,211 @@
+package encoders
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownEncoder = errors.New("unknown encoder")
+	ErrNotDescribable = errors.New("encoder is not describable")
+	ErrInvalidParams  = errors.New("invalid encoder params")
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Spec describes an encoder by its registered name and parameters,
+// it is stored alongside the encoded boards to tell how to decode them.
+type Spec struct {
+	Name   string          `json:"name"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+func (s Spec) String() string {
+	if len(s.Params) <= 0 {
+		return s.Name
+	}
+	return s.Name + string(s.Params)
+}
+
+// Equal compares the names and the params in JSON semantics.
+func (s Spec) Equal(o Spec) bool {
+	if s.Name != o.Name {
+		return false
+	}
+	if len(s.Params) <= 0 || len(o.Params) <= 0 {
+		return len(s.Params) == len(o.Params)
+	}
+	var a, b bytes.Buffer
+	if json.Compact(&a, s.Params) != nil || json.Compact(&b, o.Params) != nil {
+		return false
+	}
+	return bytes.Equal(a.Bytes(), b.Bytes())
+}
+
+// Factory builds an encoder from the params of its Spec.
+type Factory func(params json.RawMessage) (Score, error)
+
+// Describer is implemented by the encoders which can be rebuilt by the registry.
+type Describer interface {
+	Spec() (Spec, error)
+}
+
+// Register makes an encoder available by name, it panics if the name is registered twice.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		panic(fmt.Errorf("encoders: nil factory for %s", name))
+	}
+	if _, ok := registry[name]; ok {
+		panic(fmt.Errorf("encoders: %s registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// Names returns the registered names in order.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New builds the encoder described by spec.
+func New(spec Spec) (Score, error) {
+	registryMu.RLock()
+	factory, ok := registry[spec.Name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEncoder, spec.Name)
+	}
+	return factory(spec.Params)
+}
+
+// SpecOf returns the Spec of enc, ErrNotDescribable if it doesn't implement Describer.
+func SpecOf(enc Score) (Spec, error) {
+	d, ok := enc.(Describer)
+	if !ok {
+		return Spec{}, fmt.Errorf("%w: %T", ErrNotDescribable, enc)
+	}
+	return d.Spec()
+}
+
+// Equal reports whether a and b encode the same way: the same instance or equal specs.
+func Equal(a, b Score) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	sa, err := SpecOf(a)
+	if err != nil {
+		return false
+	}
+	sb, err := SpecOf(b)
+	if err != nil {
+		return false
+	}
+	return sa.Equal(sb)
+}
+
+func newSpec(name string, params interface{}) (Spec, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return Spec{}, fmt.Errorf("%w: %s: %w", ErrInvalidParams, name, err)
+	}
+	return Spec{Name: name, Params: raw}, nil
+}
+
+func parseParams(name string, params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrInvalidParams, name, err)
+	}
+	return nil
+}
+
+type compositeParams struct {
+	Fields []Field `json:"fields"`
+}
+
+type timestampParams struct {
+	Encoder Spec      `json:"encoder"`
+	Epoch   time.Time `json:"epoch"`
+	Unit    string    `json:"unit"`
+}
+
+func (se *firstInIsBigger) Spec() (Spec, error) {
+	return Spec{Name: "first_in_is_bigger"}, nil
+}
+
+func (se *lastInIsBigger) Spec() (Spec, error) {
+	return Spec{Name: "last_in_is_bigger"}, nil
+}
+
+func (b *Bits) Spec() (Spec, error) {
+	return newSpec("bits", b.cfg)
+}
+
+func (c *Composite) Spec() (Spec, error) {
+	return newSpec("composite", compositeParams{Fields: c.fields})
+}
+
+// Spec fails if the wrapped encoder is not describable.
+func (t *Timestamp) Spec() (Spec, error) {
+	enc, err := SpecOf(t.enc)
+	if err != nil {
+		return Spec{}, err
+	}
+	return newSpec("timestamp", timestampParams{Encoder: enc, Epoch: t.epoch.UTC(), Unit: t.unit.String()})
+}
+
+func init() {
+	Register("first_in_is_bigger", func(json.RawMessage) (Score, error) {
+		return FirstInIsBigger, nil
+	})
+	Register("last_in_is_bigger", func(json.RawMessage) (Score, error) {
+		return LastInIsBigger, nil
+	})
+	Register("bits", func(params json.RawMessage) (Score, error) {
+		var cfg BitsConfig
+		if err := parseParams("bits", params, &cfg); err != nil {
+			return nil, err
+		}
+		return NewBits(cfg)
+	})
+	Register("composite", func(params json.RawMessage) (Score, error) {
+		var p compositeParams
+		if err := parseParams("composite", params, &p); err != nil {
+			return nil, err
+		}
+		return NewComposite(p.Fields...)
+	})
+	Register("timestamp", func(params json.RawMessage) (Score, error) {
+		var p timestampParams
+		if err := parseParams("timestamp", params, &p); err != nil {
+			return nil, err
+		}
+		unit, err := time.ParseDuration(p.Unit)
+		if err != nil || unit <= 0 {
+			return nil, fmt.Errorf("%w: timestamp: unit \"%s\"", ErrInvalidParams, p.Unit)
+		}
+		enc, err := New(p.Encoder)
+		if err != nil {
+			return nil, err
+		}
+		return NewTimestamp(enc, p.Epoch, unit), nil
+	})
+}
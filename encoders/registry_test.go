@@ -0,0 +1,49 @@
+package encoders
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	epoch := time.Date(2025, 1, 1, 0, 0, 0, 0, time.FixedZone("UTC+8", 8*3600))
+	tests := []Score{
+		FirstInIsBigger,
+		LastInIsBigger,
+		MustBits(BitsConfig{ScoreBits: 32, FactorBits: 21, Signed: true}),
+		MustComposite(Field{Name: "wins", Bits: 16}, Field{Name: "finish", Bits: 30, Reverse: true}),
+		EarlierWins(epoch, time.Second),
+		NewTimestamp(MustBits(BitsConfig{ScoreBits: 20, FactorBits: 30}), epoch, time.Millisecond),
+	}
+	for _, enc := range tests {
+		spec, err := SpecOf(enc)
+		require.NoError(t, err)
+		t.Run(spec.Name, func(t *testing.T) {
+			raw, err := json.Marshal(spec)
+			require.NoError(t, err)
+			var parsed Spec
+			require.NoError(t, json.Unmarshal(raw, &parsed))
+			require.True(t, spec.Equal(parsed))
+
+			rebuilt, err := New(parsed)
+			require.NoError(t, err)
+			assert.True(t, Equal(enc, rebuilt))
+			assert.Equal(t, enc.Encode(3, 7), rebuilt.Encode(3, 7))
+		})
+	}
+
+	assert.False(t, Equal(FirstInIsBigger, LastInIsBigger))
+	assert.False(t, Equal(MustBits(BitsConfig{ScoreBits: 32, FactorBits: 21}), MustBits(BitsConfig{ScoreBits: 31, FactorBits: 21})))
+
+	_, err := New(Spec{Name: "absent"})
+	assert.ErrorIs(t, err, ErrUnknownEncoder)
+	_, err = New(Spec{Name: "bits", Params: json.RawMessage(`{"score_bits": 60}`)})
+	assert.Error(t, err)
+	_, err = SpecOf(&scoreI32{})
+	assert.ErrorIs(t, err, ErrNotDescribable)
+	assert.Panics(t, func() { Register("bits", nil) })
+}
@@ -0,0 +1,90 @@
+package encoders
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Timestamp takes the factor from the write time: the elapsed units since epoch.
+// The direction comes from the wrapped encoder, FirstInIsBigger for "earlier wins"
+// and LastInIsBigger for "later wins".
+// RankList fills the factor by Factor(time.Now()) when the factor passed to a write is 0,
+// a non-zero factor is encoded as it is, e.g. a time computed by Factor elsewhere.
+type Timestamp struct {
+	enc   Score
+	epoch time.Time
+	unit  time.Duration
+}
+
+func NewTimestamp(enc Score, epoch time.Time, unit time.Duration) *Timestamp {
+	if enc == nil {
+		panic(fmt.Errorf("nil encoder"))
+	}
+	if unit <= 0 {
+		panic(fmt.Errorf("invalid unit: %s", unit))
+	}
+	return &Timestamp{enc: enc, epoch: epoch, unit: unit}
+}
+
+// EarlierWins ranks the first one who reached the score higher.
+func EarlierWins(epoch time.Time, unit time.Duration) *Timestamp {
+	return NewTimestamp(FirstInIsBigger, epoch, unit)
+}
+
+// LaterWins ranks the last one who reached the score higher.
+func LaterWins(epoch time.Time, unit time.Duration) *Timestamp {
+	return NewTimestamp(LastInIsBigger, epoch, unit)
+}
+
+func (t *Timestamp) Factor(now time.Time) (int32, error) {
+	elapsed := int64(now.Sub(t.epoch) / t.unit)
+	if elapsed < 0 || elapsed > t.MaxFactor() {
+		return 0, fmt.Errorf("%w: %s is out of the range since %s in %s", ErrFactorOverflow, now, t.epoch, t.unit)
+	}
+	return int32(elapsed), nil
+}
+
+func (t *Timestamp) Encode(score int32, factor int32) int64 {
+	return t.enc.Encode(score, factor)
+}
+
+func (t *Timestamp) Decode(score int64) int32 {
+	return t.enc.Decode(score)
+}
+
+func (t *Timestamp) EncodeInt64(score int64, factor int64) (int64, error) {
+	if enc, ok := t.enc.(Score64); ok {
+		return enc.EncodeInt64(score, factor)
+	}
+	min, max := t.ScoreRange()
+	if score < min || score > max {
+		return 0, fmt.Errorf("%w: %d not in [%d, %d]", ErrScoreOverflow, score, min, max)
+	}
+	if factor < 0 || factor > t.MaxFactor() {
+		return 0, fmt.Errorf("%w: %d not in [0, %d]", ErrFactorOverflow, factor, t.MaxFactor())
+	}
+	return t.enc.Encode(int32(score), int32(factor)), nil
+}
+
+func (t *Timestamp) DecodeInt64(score int64) int64 {
+	if enc, ok := t.enc.(Score64); ok {
+		return enc.DecodeInt64(score)
+	}
+	return int64(t.enc.Decode(score))
+}
+
+func (t *Timestamp) ScoreRange() (int64, int64) {
+	if enc, ok := t.enc.(Score64); ok {
+		return enc.ScoreRange()
+	}
+	// scoreI32 collapses the scores <= 0
+	return 0, math.MaxInt32
+}
+
+func (t *Timestamp) MaxFactor() int64 {
+	if enc, ok := t.enc.(Score64); ok {
+		return min(enc.MaxFactor(), math.MaxInt32)
+	}
+	return math.MaxInt32
+}
@@ -0,0 +1,45 @@
+package encoders
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestamp(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := epoch.Add(100 * 24 * time.Hour)
+
+	encode := func(enc *Timestamp, score int64, ts time.Time) int64 {
+		factor, err := enc.Factor(ts)
+		require.NoError(t, err)
+		rs, err := enc.EncodeInt64(score, int64(factor))
+		require.NoError(t, err)
+		require.Equal(t, score, enc.DecodeInt64(rs))
+		return rs
+	}
+
+	t.Run("EarlierWins", func(t *testing.T) {
+		enc := EarlierWins(epoch, time.Second)
+		assert.Greater(t, encode(enc, 10, now), encode(enc, 10, now.Add(time.Second)))
+		assert.Less(t, encode(enc, 10, now), encode(enc, 11, now.Add(time.Second)))
+	})
+
+	t.Run("LaterWins", func(t *testing.T) {
+		enc := LaterWins(epoch, time.Second)
+		assert.Less(t, encode(enc, 10, now), encode(enc, 10, now.Add(time.Second)))
+		assert.Less(t, encode(enc, 10, now.Add(time.Hour)), encode(enc, 11, now))
+	})
+
+	t.Run("Bits", func(t *testing.T) {
+		enc := NewTimestamp(MustBits(BitsConfig{ScoreBits: 30, FactorBits: 23, Signed: true, FirstInIsBigger: true}), epoch, time.Minute)
+		assert.Greater(t, encode(enc, -10, now), encode(enc, -10, now.Add(time.Minute)))
+
+		_, err := enc.Factor(epoch.Add(-time.Minute))
+		assert.ErrorIs(t, err, ErrFactorOverflow)
+		_, err = enc.Factor(epoch.Add(1 << 23 * time.Minute))
+		assert.ErrorIs(t, err, ErrFactorOverflow)
+	})
+}
@@ -1,8 +1,100 @@
 package orderings
 
-type Odering string
+import (
+	"fmt"
+	"strings"
+)
+
+// Ordering is the direction of ranking, Desc ranks the highest score first.
+type Ordering int
 
 const (
-	Asc  Odering = "asc"
-	Desc Odering = "desc"
+	Desc Ordering = 1
+	Asc  Ordering = 2
 )
+
+// Odering is the former string ordering, "asc" or "desc".
+//
+// Deprecated: use Ordering, the former values convert with Odering.Ordering.
+type Odering string
+
+// Ordering converts the former string ordering, case-insensitive.
+func (o Odering) Ordering() (Ordering, error) {
+	return Parse(string(o))
+}
+
+// Parse parses "asc" or "desc", case-insensitive.
+func Parse(name string) (Ordering, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "asc":
+		return Asc, nil
+	case "desc":
+		return Desc, nil
+	default:
+		return 0, fmt.Errorf("invalid ordering: \"%s\"", name)
+	}
+}
+
+// MustParse is like Parse but panics on error.
+func MustParse(name string) Ordering {
+	o, err := Parse(name)
+	if err != nil {
+		panic(err)
+	}
+	return o
+}
+
+func (o Ordering) Valid() bool {
+	return o == Asc || o == Desc
+}
+
+func (o Ordering) IsDesc() bool {
+	return o == Desc
+}
+
+// Reverse returns the opposite ordering, an invalid ordering is returned as is.
+func (o Ordering) Reverse() Ordering {
+	switch o {
+	case Asc:
+		return Desc
+	case Desc:
+		return Asc
+	default:
+		return o
+	}
+}
+
+func (o Ordering) String() string {
+	switch o {
+	case Asc:
+		return "asc"
+	case Desc:
+		return "desc"
+	default:
+		return fmt.Sprintf("Ordering(%d)", int(o))
+	}
+}
+
+func (o Ordering) MarshalText() ([]byte, error) {
+	if !o.Valid() {
+		return nil, fmt.Errorf("invalid ordering: %d", int(o))
+	}
+	return []byte(o.String()), nil
+}
+
+func (o *Ordering) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*o = parsed
+	return nil
+}
+
+// Or returns the first of overrides if any, otherwise o. It is for the optional per-call ordering.
+func (o Ordering) Or(overrides ...Ordering) Ordering {
+	if len(overrides) > 0 {
+		return overrides[0]
+	}
+	return o
+}
@@ -0,0 +1,41 @@
+package orderings
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrdering(t *testing.T) {
+	o, err := Parse("ASC")
+	assert.NoError(t, err)
+	assert.Equal(t, Asc, o)
+	assert.Equal(t, Desc, o.Reverse())
+	assert.Equal(t, Asc, o.Reverse().Reverse())
+	assert.Equal(t, "desc", Desc.String())
+
+	_, err = Parse("up")
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustParse("") })
+	assert.False(t, Ordering(0).Valid())
+
+	assert.Equal(t, Desc, Desc.Or())
+	assert.Equal(t, Asc, Desc.Or(Asc))
+
+	var cfg struct {
+		Ordering Ordering `json:"ordering"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"ordering": "desc"}`), &cfg))
+	assert.Equal(t, Desc, cfg.Ordering)
+	raw, err := json.Marshal(cfg)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"ordering": "desc"}`, string(raw))
+	assert.Error(t, json.Unmarshal([]byte(`{"ordering": "up"}`), &cfg))
+
+	o, err = Odering("desc").Ordering()
+	assert.NoError(t, err)
+	assert.Equal(t, Desc, o)
+	_, err = Odering("up").Ordering()
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+package periods
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	Day     Period = day{}
+	ISOWeek Period = isoWeek{}
+	Month   Period = month{}
+)
+
+type Period interface {
+	// Start returns the start of the period containing t, in the location of t.
+	// Returns zero time if t is not in any period.
+	Start(t time.Time) time.Time
+	// Next returns the start of the period after the one starting at start, zero time if unknown.
+	Next(start time.Time) time.Time
+	// Prev returns the start of the period before the one starting at start, zero time if none.
+	Prev(start time.Time) time.Time
+	// ID returns the id of the period starting at start.
+	ID(start time.Time) string
+}
+
+type day struct{}
+
+func (day) Start(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func (day) Next(start time.Time) time.Time {
+	return start.AddDate(0, 0, 1)
+}
+
+func (day) Prev(start time.Time) time.Time {
+	return start.AddDate(0, 0, -1)
+}
+
+func (day) ID(start time.Time) string {
+	return start.Format("20060102")
+}
+
+type isoWeek struct{}
+
+func (isoWeek) Start(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // monday = 0
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+func (isoWeek) Next(start time.Time) time.Time {
+	return start.AddDate(0, 0, 7)
+}
+
+func (isoWeek) Prev(start time.Time) time.Time {
+	return start.AddDate(0, 0, -7)
+}
+
+func (isoWeek) ID(start time.Time) string {
+	year, week := start.ISOWeek()
+	return fmt.Sprintf("%04dW%02d", year, week)
+}
+
+type month struct{}
+
+func (month) Start(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+func (month) Next(start time.Time) time.Time {
+	return start.AddDate(0, 1, 0)
+}
+
+func (month) Prev(start time.Time) time.Time {
+	return start.AddDate(0, -1, 0)
+}
+
+func (month) ID(start time.Time) string {
+	return start.Format("200601")
+}
+
+type Season struct {
+	ID    string
+	Start time.Time
+}
+
+// Seasons is a custom calendar, each season lasts until the next one starts.
+type Seasons struct {
+	seasons []Season
+}
+
+func NewSeasons(seasons ...Season) *Seasons {
+	sorted := make([]Season, len(seasons))
+	copy(sorted, seasons)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	for i, s := range sorted {
+		if s.ID == "" {
+			panic(fmt.Errorf("empty season id at %s", s.Start))
+		}
+		if i > 0 && s.Start.Equal(sorted[i-1].Start) {
+			panic(fmt.Errorf("duplicated season start: %s", s.Start))
+		}
+	}
+	return &Seasons{seasons: sorted}
+}
+
+// index of the last season started at or before t, -1 if none
+func (this *Seasons) indexOf(t time.Time) int {
+	i := sort.Search(len(this.seasons), func(i int) bool {
+		return this.seasons[i].Start.After(t)
+	})
+	return i - 1
+}
+
+func (this *Seasons) Start(t time.Time) time.Time {
+	i := this.indexOf(t)
+	if i < 0 {
+		return time.Time{}
+	}
+	return this.seasons[i].Start.In(t.Location())
+}
+
+func (this *Seasons) Next(start time.Time) time.Time {
+	i := this.indexOf(start)
+	if i+1 >= len(this.seasons) {
+		return time.Time{}
+	}
+	return this.seasons[i+1].Start.In(start.Location())
+}
+
+func (this *Seasons) Prev(start time.Time) time.Time {
+	i := this.indexOf(start)
+	if i <= 0 {
+		return time.Time{}
+	}
+	return this.seasons[i-1].Start.In(start.Location())
+}
+
+func (this *Seasons) ID(start time.Time) string {
+	i := this.indexOf(start)
+	if i < 0 {
+		return ""
+	}
+	return this.seasons[i].ID
+}
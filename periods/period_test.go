@@ -0,0 +1,48 @@
+package periods
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriods(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2024, 12, 31, 23, 30, 0, 0, loc)
+
+	tests := []struct {
+		name   string
+		period Period
+		id     string
+		prevID string
+		nextID string
+	}{
+		{"day", Day, "20241231", "20241230", "20250101"},
+		{"isoweek", ISOWeek, "2025W01", "2024W52", "2025W02"},
+		{"month", Month, "202412", "202411", "202501"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := tt.period.Start(now)
+			assert.False(t, start.After(now))
+			assert.Equal(t, tt.id, tt.period.ID(start))
+			assert.Equal(t, tt.prevID, tt.period.ID(tt.period.Prev(start)))
+			assert.Equal(t, tt.nextID, tt.period.ID(tt.period.Next(start)))
+		})
+	}
+
+	t.Run("seasons", func(t *testing.T) {
+		s1 := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+		s2 := time.Date(2024, 6, 1, 0, 0, 0, 0, loc)
+		seasons := NewSeasons(Season{"s2", s2}, Season{"s1", s1})
+
+		assert.True(t, seasons.Start(s1.Add(-time.Second)).IsZero())
+
+		start := seasons.Start(now)
+		assert.Equal(t, "s2", seasons.ID(start))
+		assert.True(t, seasons.Next(start).IsZero())
+		assert.Equal(t, "s1", seasons.ID(seasons.Prev(start)))
+		assert.True(t, seasons.Prev(seasons.Prev(start)).IsZero())
+	})
+}
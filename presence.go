@@ -0,0 +1,118 @@
+package redisobj
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// remove members last seen before ARGV[1] and return them
+var luaPresencePrune = redis.NewScript(`
+local members = redis.call("ZRANGE", KEYS[1], "-inf", "(" .. ARGV[1], "BYSCORE")
+if #members > 0 then
+	redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", "(" .. ARGV[1])
+end
+return members
+`)
+
+// Presence tracks online members with per-member expiry.
+// It is a ZSet of member -> last seen time in milliseconds.
+type Presence struct {
+	core
+	onOffline func(members []string)
+}
+
+func NewPresence(redis *redis.Client, key string) *Presence {
+	return &Presence{
+		core: *newCore(redis, key),
+	}
+}
+
+// WithOnOffline sets a hook called by Prune with the members which just went offline.
+func (this *Presence) WithOnOffline(hook func(members []string)) *Presence {
+	this.onOffline = hook
+	return this
+}
+
+func (this *Presence) Heartbeat(member string) error {
+	return this.HeartbeatAt(member, time.Now())
+}
+
+func (this *Presence) HeartbeatAt(member string, ts time.Time) error {
+	c := context.TODO()
+	elem := redis.Z{Member: member, Score: float64(ts.UnixMilli())}
+	return this.redis.ZAdd(c, this.key, elem).Err()
+}
+
+// Leave removes the member immediately, without calling the offline hook.
+func (this *Presence) Leave(member string) error {
+	c := context.TODO()
+	err := this.redis.ZRem(c, this.key, member).Err()
+	if err == redis.Nil {
+		err = nil
+	}
+	return err
+}
+
+func (this *Presence) LastSeen(member string) (time.Time, error) {
+	c := context.TODO()
+	score, err := this.redis.ZScore(c, this.key, member).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return time.UnixMilli(int64(score)), nil
+}
+
+func (this *Presence) since(window time.Duration) string {
+	return strconv.FormatInt(time.Now().Add(-window).UnixMilli(), 10)
+}
+
+// Online returns the members seen within the window.
+func (this *Presence) Online(window time.Duration) ([]string, error) {
+	c := context.TODO()
+	opt := redis.ZRangeBy{Min: this.since(window), Max: "+inf"}
+	members, err := this.redis.ZRangeByScore(c, this.key, &opt).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return members, err
+}
+
+// CountOnline returns the number of members seen within the window.
+func (this *Presence) CountOnline(window time.Duration) (int64, error) {
+	c := context.TODO()
+	count, err := this.redis.ZCount(c, this.key, this.since(window), "+inf").Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+// Prune atomically removes the members not seen within the window and returns them.
+// The offline hook is called with the removed members if any.
+func (this *Presence) Prune(window time.Duration) ([]string, error) {
+	c := context.TODO()
+	keys := []string{this.key}
+	members, err := luaPresencePrune.Run(c, this.redis, keys, this.since(window)).StringSlice()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(members) > 0 && this.onOffline != nil {
+		this.onOffline(members)
+	}
+	return members, nil
+}
+
+func (this *Presence) Clear() error {
+	c := context.TODO()
+	_, err := this.redis.Unlink(c, this.key).Result()
+	return err
+}
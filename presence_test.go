@@ -0,0 +1,55 @@
+package redisobj
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresence_Prune(t *testing.T) {
+	var offline [][]string
+	presence := NewPresence(newTestClient(), "prefiex_test_Presence").WithOnOffline(func(members []string) {
+		offline = append(offline, members)
+	})
+	presence.Clear()
+	t.Cleanup(func() {
+		presence.Clear()
+	})
+
+	now := time.Now()
+	assert.NoError(t, presence.HeartbeatAt("id1", now.Add(-10*time.Minute)))
+	assert.NoError(t, presence.HeartbeatAt("id2", now.Add(-3*time.Minute)))
+	assert.NoError(t, presence.HeartbeatAt("id3", now.Add(-30*time.Second)))
+
+	online, err := presence.Online(5 * time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id2", "id3"}, online)
+	count, err := presence.CountOnline(time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	seen, err := presence.LastSeen("id2")
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(-3*time.Minute).UnixMilli(), seen.UnixMilli())
+
+	pruned, err := presence.Prune(5 * time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id1"}, pruned)
+	assert.Equal(t, [][]string{{"id1"}}, offline)
+
+	// nothing to prune, the hook is not called
+	pruned, err = presence.Prune(5 * time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, pruned)
+	assert.Equal(t, 1, len(offline))
+
+	// a member who left is not reported offline
+	assert.NoError(t, presence.Leave("id2"))
+	pruned, err = presence.Prune(10 * time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id3"}, pruned)
+	assert.Equal(t, [][]string{{"id1"}, {"id3"}}, offline)
+	seen, err = presence.LastSeen("id2")
+	assert.NoError(t, err)
+	assert.True(t, seen.IsZero())
+}
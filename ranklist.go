@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/cupen/redisobj/encoders"
+	"github.com/cupen/redisobj/orderings"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -28,21 +29,27 @@ type (
 // const OrderingAsc = 0
 // const OrderingDesc = 1
 
-// type RankItem struct {
-// 	Member string
-// 	Score  float64
-// 	Data   Dict
-// }
+type RankItem struct {
+	Member string
+	Score  float64
+	Rank   int64 // 1-based
+	Data   Dict  // only if WithData
+}
 
 type RankList struct {
 	redis   *redis.Client
 	baseKey string
 	key     string
 
-	MaxMembers int // 暂时不用
-	Order      int // 0-从高到低，1-从低到高
+	MaxMembers int                // 最大成员数, 仅在 capped 时自动裁剪
+	Order      orderings.Ordering // OrderingDesc-从高到低，OrderingAsc-从低到高
 	enc        encoders.Score
 	cond       condition
+	capped     bool
+	tie        TiePolicy
+	withData   bool
+	tracking   *RankTracking
+	meta       *rankMeta
 }
 
 func NewRankList(redis *redis.Client, baseKey string) *RankList {
@@ -54,6 +61,7 @@ func NewRankList(redis *redis.Client, baseKey string) *RankList {
 		baseKey:    baseKey,
 		key:        strings.Join([]string{baseKey, "default"}, ":"),
 		MaxMembers: 0,
+		meta:       &rankMeta{},
 	}
 	return rank.WithOrdering("desc")
 }
@@ -63,6 +71,7 @@ func (this *RankList) SetID(rankId string) {
 		panic(errors.New("baseKey was empty"))
 	}
 	this.key = strings.Join([]string{this.baseKey, rankId}, ":")
+	this._newMeta()
 }
 
 func (this *RankList) WithID(rankId string) *RankList {
@@ -77,31 +86,56 @@ func (this *RankList) WithCond(cond condition) *RankList {
 	return cloned
 }
 
+// WithOrdering sets the ordering by name in place and returns the list itself, it panics on an invalid name.
+// Unlike WithOrder, it doesn't clone, as it always did.
+//
+// Deprecated: use WithOrder with orderings.Parse.
 func (this *RankList) WithOrdering(name string) *RankList {
-	_parse := func(ordering string) int {
-		switch ordering {
-		case "asc":
-			return OrderingAsc
-		case "desc":
-			return OrderingDesc
-		default:
-			panic(fmt.Errorf("invalid order: \"%s\"", ordering))
-		}
-	}
-	this.Order = _parse(name)
+	this.Order = orderings.MustParse(name)
 	return this
 }
 
+// WithOrder returns a clone with the ordering, the list itself is left as it is.
+func (this *RankList) WithOrder(ordering orderings.Ordering) *RankList {
+	cloned := this.Clone()
+	cloned.Order = ordering
+	return cloned
+}
+
+// WithCapacity enables the capacity mode: every write trims the list to maxMembers atomically,
+// the lowest ranked members are evicted.
+func (this *RankList) WithCapacity(maxMembers int) *RankList {
+	cloned := this.Clone()
+	cloned.MaxMembers = maxMembers
+	cloned.capped = maxMembers > 0
+	return cloned
+}
+
+// WithTiePolicy sets how GetRanking, GetListWithRank and GetAround rank members with equal scores.
+func (this *RankList) WithTiePolicy(tie TiePolicy) *RankList {
+	cloned := this.Clone()
+	cloned.tie = tie
+	return cloned
+}
+
+// WithEncoder sets the encoder of scores. A describable encoder (see encoders.Describer) is stored
+// alongside the list in "key:meta" on the first use, and later uses with a different one fail
+// with ErrEncoderMismatch. Without an encoder, the stored one is detected on the first use.
 func (this *RankList) WithEncoder(enc encoders.Score) *RankList {
 	this.enc = enc
+	// the clones made before keep their encoder, and so the meta of it
+	this._newMeta()
 	return this
 }
 
 func (this *RankList) GetEncoder() encoders.Score {
-	return this.enc
+	return this._encoder()
 }
 
 func (this *RankList) GetRanking(member string) (int64, error) {
+	if this.tie != TieOrdinal {
+		return this._getTieRanking(member)
+	}
 	key := this.key
 	var ranking int64 = 0
 	var err error
@@ -121,17 +155,21 @@ func (this *RankList) GetRanking(member string) (int64, error) {
 	return ranking + 1, err
 }
 
-func (this *RankList) GetList(start int, count int) ([]redis.Z, error) {
+// GetList returns count members from start, the ordering defaults to the list's ordering.
+func (this *RankList) GetList(start int, count int, ordering ...orderings.Ordering) ([]redis.Z, error) {
 	key := this.key
 	end := start + count - 1
 	if start > end {
 		return nil, fmt.Errorf("invalid params: start(%d) > end(%d)", start, end)
 	}
+	if err := this.CheckEncoder(); err != nil {
+		return nil, err
+	}
 
 	var _list []redis.Z
 	var err error
 	c := context.TODO()
-	if this.Order == OrderingDesc {
+	if this.Order.Or(ordering...) == OrderingDesc {
 		_list, err = this.redis.ZRevRangeWithScores(c, key, int64(start), int64(end)).Result()
 	} else {
 		_list, err = this.redis.ZRangeWithScores(c, key, int64(start), int64(end)).Result()
@@ -142,7 +180,7 @@ func (this *RankList) GetList(start int, count int) ([]redis.Z, error) {
 		}
 		return nil, err
 	}
-	if this.enc != nil {
+	if this._encoder() != nil {
 		this._decodeScores(_list)
 	}
 	return _list, nil
@@ -160,10 +198,53 @@ func (this *RankList) Size() (int64, error) {
 
 func (this *RankList) _decodeScores(items []redis.Z) {
 	for i, item := range items {
-		items[i].Score = float64(this.enc.Decode(int64(item.Score)))
+		items[i].Score = this._decodeScore(item.Score)
+	}
+}
+
+func (this *RankList) _decodeScore(score float64) float64 {
+	enc := this._encoder()
+	if enc64, ok := enc.(encoders.Score64); ok {
+		return float64(enc64.DecodeInt64(int64(score)))
+	}
+	return float64(enc.Decode(int64(score)))
+}
+
+// with a TimeFactor encoder, a zero factor is filled from the current time, see Set.
+func (this *RankList) _encodeScore(score float64, factor int32) (float64, error) {
+	if err := this._checkEncoder(true); err != nil {
+		return 0, err
+	}
+	enc := this._encoder()
+	if enc == nil {
+		return score, nil
+	}
+	if tf, ok := enc.(encoders.TimeFactor); ok && factor == 0 {
+		var err error
+		factor, err = tf.Factor(time.Now())
+		if err != nil {
+			return 0, err
+		}
 	}
+	if enc64, ok := enc.(encoders.Score64); ok {
+		if score != math.Trunc(score) || math.Abs(score) > 1<<encoders.MaxSafeBits {
+			return 0, fmt.Errorf("%w: %f", encoders.ErrScoreOverflow, score)
+		}
+		encoded, err := enc64.EncodeInt64(int64(score), int64(factor))
+		return float64(encoded), err
+	}
+	// the legacy encoders take scores in [0, MaxInt32], a negative one would be stored as 0.
+	// Note: the factor is exact only up to 2^22, larger scores round the tie-break of the float64.
+	if score < 0 || score > math.MaxInt32 {
+		slog.Error("[redisobj.RankList] Set: score out of range", "score", score, "factor", factor)
+		return 0, fmt.Errorf("%w: %f not in [0, %d]", encoders.ErrScoreOverflow, score, math.MaxInt32)
+	}
+	return float64(enc.Encode(int32(score), factor)), nil
 }
 
+// Set sets the score of member, the factor breaks the ties of equal scores with an encoder.
+// With a TimeFactor encoder (e.g. encoders.EarlierWins), factor 0 means "now": the factor is taken
+// from the current time, so 0 itself can't be written. The other writes taking a factor do the same.
 func (this *RankList) Set(member string, score float64, factor int32) (int64, error) {
 	if this.cond != nil {
 		if !this.cond(member) {
@@ -171,23 +252,187 @@ func (this *RankList) Set(member string, score float64, factor int32) (int64, er
 		}
 	}
 
-	if this.enc != nil {
-		if score > math.MaxInt32 {
-			slog.Error("[redisobj.RankList] Set: score too large", "score", score, "factor", factor)
-			return 0, fmt.Errorf("score too large: %f", score)
-		}
-		score = float64(this.enc.Encode(int32(score), factor))
+	score, err := this._encodeScore(score, factor)
+	if err != nil {
+		return 0, err
 	}
-	key := this.key
 	m := redis.Z{
 		Score:  score,
 		Member: member,
 	}
+	return this._set(m)
+}
+
+// writes an encoded score, tracked and trimmed if enabled
+func (this *RankList) _set(m redis.Z) (int64, error) {
+	if this.tracking != nil {
+		added, _, err := this._trackedSet(m, this._maxMembers())
+		return added, err
+	}
+	if this.capped {
+		added, _, err := zaddAndTrim(this.redis, this._trimKeys(), this.MaxMembers, this.Order, []redis.Z{m})
+		return added, err
+	}
+	c := context.TODO()
+	return this.redis.ZAdd(c, this.key, m).Result()
+}
+
+// the limit applied on every write, 0 if not in the capacity mode
+func (this *RankList) _maxMembers() int {
+	if this.capped {
+		return this.MaxMembers
+	}
+	return 0
+}
+
+// the script writing an encoded score with the ZADD flags in the tracking or the capacity mode
+func (this *RankList) _zaddScript(flags string, m redis.Z) (*redis.Script, []string, []interface{}) {
+	if this.tracking != nil {
+		keys, args := this._trackedSetArgs(m, this._maxMembers(), flags)
+		return luaRankListTrackedSet, keys, args
+	}
+	return luaZAddFlagsAndTrim, this._trimKeys(), zaddFlagsAndTrimArgs(this.MaxMembers, this.Order, flags, m)
+}
+
+// SetWithLimit sets the score and trims the list to MaxMembers in one script, returns the evicted members.
+func (this *RankList) SetWithLimit(member string, score float64, factor int32) ([]string, error) {
+	if this.cond != nil {
+		if !this.cond(member) {
+			return nil, ErrCondFalse
+		}
+	}
+
+	score, err := this._encodeScore(score, factor)
+	if err != nil {
+		return nil, err
+	}
+	m := redis.Z{Score: score, Member: member}
+	if this.tracking != nil {
+		_, evicted, err := this._trackedSet(m, this.MaxMembers)
+		return evicted, err
+	}
+	_, evicted, err := zaddAndTrim(this.redis, this._trimKeys(), this.MaxMembers, this.Order, []redis.Z{m})
+	return evicted, err
+}
+
+func (this *RankList) _setArgs(args redis.ZAddArgs, member string, score float64, factor int32) (bool, error) {
+	if this.cond != nil {
+		if !this.cond(member) {
+			return false, ErrCondFalse
+		}
+	}
+
+	score, err := this._encodeScore(score, factor)
+	if err != nil {
+		return false, err
+	}
+	args.Ch = true
+	m := redis.Z{Member: member, Score: score}
+	c := context.TODO()
+	if this.tracking != nil || this.capped {
+		script, keys, argv := this._zaddScript(zaddFlags(args), m)
+		rs, err := script.Run(c, this.redis, keys, argv...).Slice()
+		if err != nil {
+			return false, err
+		}
+		changed, _ := parseZAddAndTrim(rs)
+		return changed > 0, nil
+	}
+	args.Members = []redis.Z{m}
+	count, err := this.redis.ZAddArgs(c, this.key, args).Result()
+	return count > 0, err
+}
+
+// SetIfGreater sets the score only if it is greater than the current one or the member is absent.
+// With an encoder, equal scores are compared by the encoded factor.
+func (this *RankList) SetIfGreater(member string, score float64, factor int32) (bool, error) {
+	return this._setArgs(redis.ZAddArgs{GT: true}, member, score, factor)
+}
+
+// SetIfLess sets the score only if it is less than the current one or the member is absent.
+// With an encoder, equal scores are compared by the encoded factor.
+func (this *RankList) SetIfLess(member string, score float64, factor int32) (bool, error) {
+	return this._setArgs(redis.ZAddArgs{LT: true}, member, score, factor)
+}
+
+// AddIfAbsent adds the member only if it does not exist.
+func (this *RankList) AddIfAbsent(member string, score float64, factor int32) (bool, error) {
+	return this._setArgs(redis.ZAddArgs{NX: true}, member, score, factor)
+}
+
+// UpdateOnly updates the score only if the member exists.
+func (this *RankList) UpdateOnly(member string, score float64, factor int32) (bool, error) {
+	return this._setArgs(redis.ZAddArgs{XX: true}, member, score, factor)
+}
+
+// IncrScore atomically adds delta to the score of member and returns the new score.
+// With an encoder, the score is decoded, incremented and encoded again with factor in an optimistic
+// transaction, so the encoded tie-break stays valid.
+func (this *RankList) IncrScore(member string, delta float64, factor int32) (float64, error) {
+	if this.cond != nil {
+		if !this.cond(member) {
+			return 0, ErrCondFalse
+		}
+	}
+
+	if err := this._checkEncoder(true); err != nil {
+		return 0, err
+	}
+	key := this.key
 	c := context.TODO()
-	return this.redis.ZAdd(c, key, m).Result()
+	if this._encoder() == nil {
+		if this.tracking != nil || this.capped {
+			script, keys, args := this._zaddScript("INCR", redis.Z{Member: member, Score: delta})
+			rs, err := script.Run(c, this.redis, keys, args...).Slice()
+			if err != nil {
+				return 0, err
+			}
+			return parseZIncrAndTrim(rs)
+		}
+		return this.redis.ZIncrBy(c, key, delta, member).Result()
+	}
+
+	var newScore float64
+	txf := func(tx *redis.Tx) error {
+		score, err := tx.ZScore(c, key, member).Result()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if err == nil {
+			score = this._decodeScore(score)
+		}
+		newScore = score + delta
+		encoded, err := this._encodeScore(newScore, factor)
+		if err != nil {
+			return err
+		}
+		m := redis.Z{Member: member, Score: encoded}
+		_, err = tx.TxPipelined(c, func(pipe redis.Pipeliner) error {
+			if this.tracking != nil || this.capped {
+				script, keys, args := this._zaddScript("", m)
+				script.Eval(c, pipe, keys, args...)
+			} else {
+				pipe.ZAdd(c, key, m)
+			}
+			return nil
+		})
+		return err
+	}
+	const maxRetries = 16
+	for i := 0; i < maxRetries; i++ {
+		err := this.redis.Watch(c, txf, key)
+		if err == redis.TxFailedErr {
+			continue
+		}
+		return newScore, err
+	}
+	return 0, fmt.Errorf("ranklist.IncrScore: too many conflicts on %s", key)
 }
 
 func (this *RankList) GetScore(member string) (float64, error) {
+	if err := this.CheckEncoder(); err != nil {
+		return 0, err
+	}
 	key := this.key
 	c := context.TODO()
 	score, err := this.redis.ZScore(c, key, member).Result()
@@ -198,8 +443,8 @@ func (this *RankList) GetScore(member string) (float64, error) {
 		return 0, nil
 	}
 
-	if this.enc != nil {
-		score = float64(this.enc.Decode(int64(score)))
+	if this._encoder() != nil {
+		score = this._decodeScore(score)
 	}
 	return score, err
 }
@@ -212,11 +457,8 @@ func (this *RankList) GetScoreByRanking(ranking int) (float64, error) {
 	if len(items) <= 0 {
 		return 0, nil
 	}
-	score := items[0].Score
-	if this.enc != nil {
-		score = float64(this.enc.Decode(int64(score)))
-	}
-	return score, nil
+	// decoded by GetList
+	return items[0].Score, nil
 }
 
 func (this *RankList) LimitIf() (int64, error) {
@@ -224,18 +466,19 @@ func (this *RankList) LimitIf() (int64, error) {
 		// unlimit
 		return 0, nil
 	}
-	outOfRanking := this.MaxMembers + 1
-	return this.DeleteByRanking(outOfRanking, 3)
+	_, evicted, err := zaddAndTrim(this.redis, this._trimKeys(), this.MaxMembers, this.Order, nil)
+	return int64(len(evicted)), err
 }
 
-func (this *RankList) GetTop(count int) ([]redis.Z, error) {
-	return this.GetList(0, count)
+func (this *RankList) GetTop(count int, ordering ...orderings.Ordering) ([]redis.Z, error) {
+	return this.GetList(0, count, ordering...)
 }
 
 func (this *RankList) Clear() error {
-	key := this.key
+	keys := append(this._trimKeys(), this._metaKey())
 	c := context.TODO()
-	err := this.redis.Unlink(c, key).Err()
+	err := this.redis.Unlink(c, keys...).Err()
+	this._resetMeta()
 	return err
 }
 
@@ -246,6 +489,17 @@ func (this *RankList) SetMaxSize(maxSize int) {
 func (this *RankList) Delete(member string) (bool, error) {
 	key := this.key
 	c := context.TODO()
+	if companions := this._companionKeys(); len(companions) > 0 {
+		var cmd *redis.IntCmd
+		_, err := this.redis.TxPipelined(c, func(pipe redis.Pipeliner) error {
+			cmd = pipe.ZRem(c, key, member)
+			for _, k := range companions {
+				pipe.HDel(c, k, member)
+			}
+			return nil
+		})
+		return cmd.Val() > 0, err
+	}
 	delCount, err := this.redis.ZRem(c, key, member).Result()
 	return delCount > 0, err
 }
@@ -271,6 +525,9 @@ func (this *RankList) DeleteByRanking(ranking int, count int) (int64, error) {
 	}
 	key := this.key
 	c := context.TODO()
+	if len(this._companionKeys()) > 0 {
+		return luaRankListDeleteByRank.Run(c, this.redis, this._trimKeys(), start, end).Int64()
+	}
 	delCount, err := this.redis.ZRemRangeByRank(c, key, start, end).Result()
 	if err == redis.Nil {
 		err = nil
@@ -294,10 +551,29 @@ func (this *RankList) FullKey() string {
 	return this.key
 }
 
+// SetTTL expires the list along with its companion hashes and meta.
 func (this *RankList) SetTTL(ttl time.Duration) error {
-	key := this.key
 	c := context.TODO()
-	err := this.redis.Expire(c, key, ttl).Err()
+	_, err := this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for _, key := range this._trimKeys() {
+			pipe.Expire(c, key, ttl)
+		}
+		pipe.Expire(c, this._metaKey(), ttl)
+		return nil
+	})
+	return err
+}
+
+// SetTTLAt is like SetTTL but expires at ts.
+func (this *RankList) SetTTLAt(ts time.Time) error {
+	c := context.TODO()
+	_, err := this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for _, key := range this._trimKeys() {
+			pipe.ExpireAt(c, key, ts)
+		}
+		pipe.ExpireAt(c, this._metaKey(), ts)
+		return nil
+	})
 	return err
 }
 
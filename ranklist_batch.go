@@ -0,0 +1,89 @@
+package redisobj
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RankEntry struct {
+	Member string
+	Score  float64
+	Factor int32
+}
+
+// SetBatch sets the entries in one round trip, the cond filter and the encoder are applied to every entry.
+// errs[i] is the error of entries[i], e.g. ErrCondFalse or ErrScoreOverflow, nil if it was written.
+// err is the error of the round trip.
+func (this *RankList) SetBatch(entries []RankEntry) (errs []error, err error) {
+	errs = make([]error, len(entries))
+	elems := make([]redis.Z, 0, len(entries))
+	indexes := make([]int, 0, len(entries))
+	for i, e := range entries {
+		if this.cond != nil && !this.cond(e.Member) {
+			errs[i] = ErrCondFalse
+			continue
+		}
+		score, err := this._encodeScore(e.Score, e.Factor)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		elems = append(elems, redis.Z{Member: e.Member, Score: score})
+		indexes = append(indexes, i)
+	}
+	if len(elems) <= 0 {
+		return errs, nil
+	}
+
+	c := context.TODO()
+	if this.tracking != nil {
+		err = this._trackedSetBatch(elems, indexes, errs)
+		return errs, err
+	}
+	if this.capped {
+		_, _, err = zaddAndTrim(this.redis, this._trimKeys(), this.MaxMembers, this.Order, elems)
+		return errs, err
+	}
+	err = this.redis.ZAdd(c, this.key, elems...).Err()
+	return errs, err
+}
+
+// runs the tracking script by sha for each of elems and copies the error of elems[i] into errs[indexes[i]],
+// the script is loaded and the entries are retried once on NOSCRIPT.
+func (this *RankList) _trackedSetBatch(elems []redis.Z, indexes []int, errs []error) error {
+	c := context.TODO()
+	pending := make([]int, len(elems))
+	for i := range pending {
+		pending[i] = i
+	}
+	for retry := 0; len(pending) > 0; retry++ {
+		if retry > 0 {
+			if err := luaRankListTrackedSet.Load(c, this.redis).Err(); err != nil {
+				return err
+			}
+		}
+		cmds := make([]*redis.Cmd, len(pending))
+		_, err := this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for i, j := range pending {
+				keys, args := this._trackedSetArgs(elems[j], this._maxMembers(), "")
+				cmds[i] = luaRankListTrackedSet.EvalSha(c, pipe, keys, args...)
+			}
+			return nil
+		})
+		if _, ok := err.(redis.Error); err != nil && !ok {
+			return err
+		}
+		noscript := pending[:0]
+		for i, j := range pending {
+			err := cmds[i].Err()
+			if retry == 0 && redis.HasErrorPrefix(err, "NOSCRIPT") {
+				noscript = append(noscript, j)
+				continue
+			}
+			errs[indexes[j]] = err
+		}
+		pending = noscript
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package redisobj
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cupen/redisobj/encoders"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrNotComposite = errors.New("encoder is not a composite")
+)
+
+func (this *RankList) _composite() (*encoders.Composite, error) {
+	if err := this.CheckEncoder(); err != nil {
+		return nil, err
+	}
+	enc, ok := this._encoder().(*encoders.Composite)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrNotComposite, this._encoder())
+	}
+	return enc, nil
+}
+
+func (this *RankList) _setComposite(member string, encode func(*encoders.Composite) (int64, error)) (int64, error) {
+	if this.cond != nil {
+		if !this.cond(member) {
+			return 0, ErrCondFalse
+		}
+	}
+	if err := this._checkEncoder(true); err != nil {
+		return 0, err
+	}
+	enc, err := this._composite()
+	if err != nil {
+		return 0, err
+	}
+	score, err := encode(enc)
+	if err != nil {
+		return 0, err
+	}
+	return this._set(redis.Z{Member: member, Score: float64(score)})
+}
+
+// SetFields sets the score of member from the values of all composite fields, in the order of fields.
+// The encoder must be an *encoders.Composite.
+func (this *RankList) SetFields(member string, values ...int64) (int64, error) {
+	return this._setComposite(member, func(enc *encoders.Composite) (int64, error) {
+		return enc.EncodeFields(values...)
+	})
+}
+
+// SetStruct is like SetFields, but takes the values from the integer fields of v.
+// See encoders.Composite.EncodeStruct.
+func (this *RankList) SetStruct(member string, v interface{}) (int64, error) {
+	return this._setComposite(member, func(enc *encoders.Composite) (int64, error) {
+		return enc.EncodeStruct(v)
+	})
+}
+
+func (this *RankList) _getComposite(member string) (*encoders.Composite, int64, error) {
+	enc, err := this._composite()
+	if err != nil {
+		return nil, 0, err
+	}
+	c := context.TODO()
+	score, err := this.redis.ZScore(c, this.key, member).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	return enc, int64(score), nil
+}
+
+// GetFields returns the values of all composite fields of member, ErrNil if member is absent.
+func (this *RankList) GetFields(member string) ([]int64, error) {
+	enc, score, err := this._getComposite(member)
+	if err != nil {
+		return nil, err
+	}
+	return enc.DecodeFields(score), nil
+}
+
+// GetStruct decodes the score of member into the struct pointed by v, ErrNil if member is absent.
+func (this *RankList) GetStruct(member string, v interface{}) error {
+	enc, score, err := this._getComposite(member)
+	if err != nil {
+		return err
+	}
+	return enc.DecodeStruct(score, v)
+}
@@ -0,0 +1,127 @@
+package redisobj
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// remove members by rank range with their fields in companion hashes
+// KEYS: zset, companion hashes of members...; ARGV: start, stop
+var luaRankListDeleteByRank = redis.NewScript(luaHDelAllFunc + `
+local members = redis.call("ZRANGE", KEYS[1], ARGV[1], ARGV[2])
+if #members > 0 then
+	redis.call("ZREMRANGEBYRANK", KEYS[1], ARGV[1], ARGV[2])
+	for i = 2, #KEYS do
+		hdel_all(KEYS[i], members)
+	end
+end
+return #members
+`)
+
+// WithData enables the member data stored in the companion hash "key:data".
+// The data is returned by GetListWithRank, GetAround and GetListWithin, and removed along with the member.
+func (this *RankList) WithData() *RankList {
+	cloned := this.Clone()
+	cloned.withData = true
+	return cloned
+}
+
+func (this *RankList) _dataKey() string {
+	return this.key + ":data"
+}
+
+// the hashes keyed by member, cleaned up along with the members
+func (this *RankList) _companionKeys() []string {
+	var keys []string
+	if this.withData {
+		keys = append(keys, this._dataKey())
+	}
+	if this.tracking != nil {
+		keys = append(keys, this._ranksKey())
+	}
+	return keys
+}
+
+// the keys of luaZAddAndTrim
+func (this *RankList) _trimKeys() []string {
+	return append([]string{this.key}, this._companionKeys()...)
+}
+
+// SetWithData sets the score and the data of member in one transaction.
+// In the capacity mode, the list is trimmed in the same transaction.
+func (this *RankList) SetWithData(member string, score float64, factor int32, data Dict) (int64, error) {
+	if this.cond != nil {
+		if !this.cond(member) {
+			return 0, ErrCondFalse
+		}
+	}
+
+	score, err := this._encodeScore(score, factor)
+	if err != nil {
+		return 0, err
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+	key := this.key
+	m := redis.Z{Score: score, Member: member}
+	c := context.TODO()
+	var zaddCmd *redis.IntCmd
+	var trimCmd *redis.Cmd
+	_, err = this.redis.TxPipelined(c, func(pipe redis.Pipeliner) error {
+		pipe.HSet(c, this._dataKey(), member, string(raw))
+		if this.tracking != nil {
+			keys, args := this._trackedSetArgs(m, this._maxMembers(), "")
+			trimCmd = luaRankListTrackedSet.Eval(c, pipe, keys, args...)
+		} else if this.capped {
+			args := zaddAndTrimArgs(this.MaxMembers, this.Order, []redis.Z{m})
+			trimCmd = luaZAddAndTrim.Eval(c, pipe, this._trimKeys(), args...)
+		} else {
+			zaddCmd = pipe.ZAdd(c, key, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if trimCmd != nil {
+		rs, err := trimCmd.Slice()
+		if err != nil {
+			return 0, err
+		}
+		added, _ := parseZAddAndTrim(rs)
+		return added, nil
+	}
+	return zaddCmd.Val(), nil
+}
+
+// GetData returns the data of member, nil if absent.
+func (this *RankList) GetData(member string) (Dict, error) {
+	c := context.TODO()
+	raw, err := this.redis.HGet(c, this._dataKey(), member).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var data Dict
+	err = json.Unmarshal([]byte(raw), &data)
+	return data, err
+}
+
+func (this *RankList) _parseData(items []RankItem, raws []interface{}) error {
+	for i, raw := range raws {
+		s, ok := raw.(string)
+		if !ok || i >= len(items) {
+			continue
+		}
+		if err := json.Unmarshal([]byte(s), &items[i].Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package redisobj
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type ExportFormat string
+
+const (
+	ExportCSV   ExportFormat = "csv"
+	ExportJSONL ExportFormat = "jsonl"
+)
+
+const exportBatchSize = 1000
+
+var exportCSVHeader = []string{"rank", "member", "score", "raw", "data"}
+
+// one exported member, Raw is the stored score so that Import restores the encoded tie-break
+type rankRecord struct {
+	Rank   int64   `json:"rank"`
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+	Raw    float64 `json:"raw"`
+	Data   Dict    `json:"data,omitempty"`
+}
+
+// Snapshot copies the list (and its member data if WithData) atomically to "key:snapshot:<name>",
+// replacing the previous snapshot of the same name. ttl <= 0 means no TTL.
+func (this *RankList) Snapshot(name string, ttl time.Duration) (*RankList, error) {
+	if name == "" {
+		return nil, ErrEmptyKey
+	}
+	snapshot := this.Clone()
+	snapshot.key = this.key + ":snapshot:" + name
+	snapshot.capped = false
+	snapshot._newMeta()
+
+	db := this.redis.Options().DB
+	c := context.TODO()
+	_, err := this.redis.TxPipelined(c, func(pipe redis.Pipeliner) error {
+		// COPY leaves the destination as it was if the source is missing
+		pipe.Del(c, snapshot.key, snapshot._metaKey(), snapshot._dataKey())
+		pipe.Copy(c, this.key, snapshot.key, db, true)
+		pipe.Copy(c, this._metaKey(), snapshot._metaKey(), db, true)
+		if this.withData {
+			pipe.Copy(c, this._dataKey(), snapshot._dataKey(), db, true)
+		}
+		if ttl > 0 {
+			pipe.Expire(c, snapshot.key, ttl)
+			pipe.Expire(c, snapshot._metaKey(), ttl)
+			if this.withData {
+				pipe.Expire(c, snapshot._dataKey(), ttl)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// Export writes all members in ranking order with their decoded and stored scores.
+// The list is read page by page, export a Snapshot for a consistent view of a live list.
+func (this *RankList) Export(w io.Writer, format ExportFormat) error {
+	var write func(rankRecord) error
+	var flush func() error
+	switch format {
+	case ExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportCSVHeader); err != nil {
+			return err
+		}
+		write = func(r rankRecord) error {
+			data := ""
+			if r.Data != nil {
+				raw, err := json.Marshal(r.Data)
+				if err != nil {
+					return err
+				}
+				data = string(raw)
+			}
+			return cw.Write([]string{
+				strconv.FormatInt(r.Rank, 10),
+				r.Member,
+				strconv.FormatFloat(r.Score, 'f', -1, 64),
+				strconv.FormatFloat(r.Raw, 'f', -1, 64),
+				data,
+			})
+		}
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	case ExportJSONL:
+		bw := bufio.NewWriter(w)
+		enc := json.NewEncoder(bw)
+		write = func(r rankRecord) error {
+			return enc.Encode(r)
+		}
+		flush = bw.Flush
+	default:
+		return fmt.Errorf("invalid export format: \"%s\"", format)
+	}
+
+	var last RankItem
+	var lastRaw float64
+	for start := 0; ; start += exportBatchSize {
+		var items []RankItem
+		var raws []float64
+		var err error
+		if start == 0 {
+			items, raws, err = this._getPage(start, exportBatchSize)
+		} else {
+			items, raws, err = this._getNextPage(start, exportBatchSize, last, lastRaw)
+		}
+		if err != nil {
+			return err
+		}
+		for i, item := range items {
+			err := write(rankRecord{
+				Rank:   item.Rank,
+				Member: item.Member,
+				Score:  item.Score,
+				Raw:    raws[i],
+				Data:   item.Data,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(items) < exportBatchSize {
+			break
+		}
+		last, lastRaw = items[len(items)-1], raws[len(raws)-1]
+	}
+	return flush()
+}
+
+// like _getPage but the ranking of the first item is carried from the last item of the previous page,
+// so that a full scan doesn't rank every page by tie_rank, which is O(N) and limited with TieDense.
+func (this *RankList) _getNextPage(start int, count int, last RankItem, lastRaw float64) ([]RankItem, []float64, error) {
+	rs, err := this._runPage(start, count, TieOrdinal)
+	if err != nil || rs == nil {
+		return nil, nil, err
+	}
+	first := int64(start + 1)
+	if flat, _ := rs[2].([]interface{}); len(flat) > 1 && this.tie != TieOrdinal {
+		scoreStr, _ := flat[1].(string)
+		score, err := strconv.ParseFloat(scoreStr, 64)
+		switch {
+		case err == nil && score == lastRaw:
+			first = last.Rank
+		case this.tie == TieDense:
+			first = last.Rank + 1
+		}
+	}
+	rs[1] = first
+	return this._parseRankItems(rs)
+}
+
+// Import restores the members written by Export with their stored scores, returns the count of members.
+// The member data is restored only if WithData. In the capacity mode, every batch is trimmed to MaxMembers.
+func (this *RankList) Import(r io.Reader, format ExportFormat) (int, error) {
+	var read func() (rankRecord, error)
+	switch format {
+	case ExportCSV:
+		cr := csv.NewReader(r)
+		header, err := cr.Read()
+		if err != nil {
+			if err == io.EOF {
+				return 0, nil
+			}
+			return 0, err
+		}
+		columns := make(map[string]int, len(header))
+		for i, name := range header {
+			columns[name] = i
+		}
+		for _, name := range []string{"member", "raw"} {
+			if _, ok := columns[name]; !ok {
+				return 0, fmt.Errorf("invalid csv: missing column \"%s\"", name)
+			}
+		}
+		read = func() (rankRecord, error) {
+			var rec rankRecord
+			row, err := cr.Read()
+			if err != nil {
+				return rec, err
+			}
+			rec.Member = row[columns["member"]]
+			rec.Raw, err = strconv.ParseFloat(row[columns["raw"]], 64)
+			if err != nil {
+				return rec, fmt.Errorf("invalid raw score of %s: %w", rec.Member, err)
+			}
+			if i, ok := columns["data"]; ok && row[i] != "" {
+				if err := json.Unmarshal([]byte(row[i]), &rec.Data); err != nil {
+					return rec, fmt.Errorf("invalid data of %s: %w", rec.Member, err)
+				}
+			}
+			return rec, nil
+		}
+	case ExportJSONL:
+		dec := json.NewDecoder(r)
+		read = func() (rankRecord, error) {
+			var rec rankRecord
+			err := dec.Decode(&rec)
+			return rec, err
+		}
+	default:
+		return 0, fmt.Errorf("invalid export format: \"%s\"", format)
+	}
+
+	// the raw scores are stored as they are, store the encoder spec as a write would
+	if err := this._checkEncoder(true); err != nil {
+		return 0, err
+	}
+	total := 0
+	batch := make([]rankRecord, 0, exportBatchSize)
+	for {
+		rec, err := read()
+		if err != nil && err != io.EOF {
+			return total, err
+		}
+		if err == nil {
+			batch = append(batch, rec)
+		}
+		if len(batch) >= exportBatchSize || (err == io.EOF && len(batch) > 0) {
+			if err := this._importBatch(batch); err != nil {
+				return total, err
+			}
+			total += len(batch)
+			batch = batch[:0]
+		}
+		if err == io.EOF {
+			return total, nil
+		}
+	}
+}
+
+func (this *RankList) _importBatch(batch []rankRecord) error {
+	key := this.key
+	c := context.TODO()
+	_, err := this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		elems := make([]redis.Z, 0, len(batch))
+		for _, rec := range batch {
+			elems = append(elems, redis.Z{Member: rec.Member, Score: rec.Raw})
+			if this.withData && rec.Data != nil {
+				raw, err := json.Marshal(rec.Data)
+				if err != nil {
+					return err
+				}
+				pipe.HSet(c, this._dataKey(), rec.Member, string(raw))
+			}
+		}
+		if this.capped {
+			luaZAddAndTrim.Eval(c, pipe, this._trimKeys(), zaddAndTrimArgs(this.MaxMembers, this.Order, elems)...)
+		} else {
+			pipe.ZAdd(c, key, elems...)
+		}
+		return nil
+	})
+	return err
+}
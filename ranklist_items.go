@@ -0,0 +1,345 @@
+package redisobj
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cupen/redisobj/orderings"
+	"github.com/redis/go-redis/v9"
+)
+
+// TiePolicy decides the ranking of members with equal scores.
+// Scores are compared as stored, so with an encoder ties are already broken by the factor.
+type TiePolicy int
+
+const (
+	TieOrdinal     TiePolicy = iota // 1,2,3,4
+	TieCompetition                  // 1,2,2,4
+	// TieDense ranks 1,2,2,3. The ranking is counted by walking the distinct better scores one by one,
+	// so it costs O(ranking * log(N)) in Redis, and GetRanking, GetListWithRank, GetAround and Export
+	// fail with ErrDenseRankLimit for rankings beyond MaxDenseRanking.
+	TieDense
+)
+
+// MaxDenseRanking is the deepest ranking computed with TieDense, which bounds the time blocking Redis.
+var MaxDenseRanking = 10000
+
+var (
+	ErrDenseRankLimit = errors.New("dense ranking over MaxDenseRanking")
+)
+
+// tie_rank(key, desc(1/0), score, policy, limit) returns the 1-based ranking of score,
+// raises DENSE_RANK_LIMIT if a dense ranking is over limit
+const luaTieRankFunc = `
+local function tie_rank(key, desc, score, policy, limit)
+	if policy == "1" then
+		if desc == "1" then
+			return redis.call("ZCOUNT", key, "(" .. score, "+inf") + 1
+		end
+		return redis.call("ZCOUNT", key, "-inf", "(" .. score) + 1
+	end
+	-- dense: walk the distinct better scores without loading them all
+	local n, cur = 0, score
+	while true do
+		local items
+		if desc == "1" then
+			items = redis.call("ZRANGE", key, "(" .. cur, "+inf", "BYSCORE", "LIMIT", 0, 1, "WITHSCORES")
+		else
+			items = redis.call("ZRANGE", key, "(" .. cur, "-inf", "BYSCORE", "REV", "LIMIT", 0, 1, "WITHSCORES")
+		end
+		if #items == 0 then
+			return n + 1
+		end
+		n = n + 1
+		if n >= tonumber(limit) then
+			error("DENSE_RANK_LIMIT")
+		end
+		cur = items[2]
+	end
+end
+`
+
+// fetch_data(key, items) returns the data of members in {member1, score1, ...}, {} if key is nil
+const luaFetchDataFunc = `
+local function fetch_data(key, items)
+	if not key or #items == 0 then
+		return {}
+	end
+	local members = {}
+	for i = 1, #items, 2 do
+		members[#members + 1] = items[i]
+	end
+	return redis.call("HMGET", key, unpack(members))
+end
+`
+
+// ARGV: desc(1/0), member, policy, dense limit
+var luaRankListTieRanking = redis.NewScript(luaTieRankFunc + `
+local score = redis.call("ZSCORE", KEYS[1], ARGV[2])
+if not score then
+	return 0
+end
+return tie_rank(KEYS[1], ARGV[1], score, ARGV[3], ARGV[4])
+`)
+
+// KEYS: ranklist, [hash of member data]; ARGV: desc(1/0), start, stop, policy, dense limit
+// returns {start, first ranking, {member1, score1, ...}, {data1, ...}}
+var luaRankListPage = redis.NewScript(luaTieRankFunc + luaFetchDataFunc + `
+local items
+if ARGV[1] == "1" then
+	items = redis.call("ZREVRANGE", KEYS[1], ARGV[2], ARGV[3], "WITHSCORES")
+else
+	items = redis.call("ZRANGE", KEYS[1], ARGV[2], ARGV[3], "WITHSCORES")
+end
+local first = tonumber(ARGV[2]) + 1
+if ARGV[4] ~= "0" and #items > 0 then
+	first = tie_rank(KEYS[1], ARGV[1], items[2], ARGV[4], ARGV[5])
+end
+return {tonumber(ARGV[2]), first, items, fetch_data(KEYS[2], items)}
+`)
+
+// the members around ARGV[2], KEYS: ranklist, [hash of member data]; ARGV: desc(1/0), member, above, below, policy, dense limit
+// returns {start, first ranking, {member1, score1, ...}, {data1, ...}}
+var luaRankListAround = redis.NewScript(luaTieRankFunc + luaFetchDataFunc + `
+local rank
+if ARGV[1] == "1" then
+	rank = redis.call("ZREVRANK", KEYS[1], ARGV[2])
+else
+	rank = redis.call("ZRANK", KEYS[1], ARGV[2])
+end
+if not rank then
+	return false
+end
+local start = rank - tonumber(ARGV[3])
+if start < 0 then
+	start = 0
+end
+local stop = rank + tonumber(ARGV[4])
+local items
+if ARGV[1] == "1" then
+	items = redis.call("ZREVRANGE", KEYS[1], start, stop, "WITHSCORES")
+else
+	items = redis.call("ZRANGE", KEYS[1], start, stop, "WITHSCORES")
+end
+local first = start + 1
+if ARGV[5] ~= "0" and #items > 0 then
+	first = tie_rank(KEYS[1], ARGV[1], items[2], ARGV[5], ARGV[6])
+end
+return {start, first, items, fetch_data(KEYS[2], items)}
+`)
+
+// the ranklist within a set, KEYS: ranklist, set, tmp, [hash of member data]; ARGV: desc(1/0), start, stop, policy, member, dense limit
+// returns {start, first ranking, {member1, score1, ...}, {data1, ...}, ranking of member}
+var luaRankListWithin = redis.NewScript(luaTieRankFunc + luaFetchDataFunc + `
+redis.call("ZINTERSTORE", KEYS[3], 2, KEYS[1], KEYS[2], "WEIGHTS", 1, 0)
+-- in case the script fails before the DEL
+redis.call("PEXPIRE", KEYS[3], 10000)
+local items = {}
+local start = tonumber(ARGV[2])
+if start <= tonumber(ARGV[3]) then
+	if ARGV[1] == "1" then
+		items = redis.call("ZREVRANGE", KEYS[3], start, ARGV[3], "WITHSCORES")
+	else
+		items = redis.call("ZRANGE", KEYS[3], start, ARGV[3], "WITHSCORES")
+	end
+end
+local first = start + 1
+if ARGV[4] ~= "0" and #items > 0 then
+	first = tie_rank(KEYS[3], ARGV[1], items[2], ARGV[4], ARGV[6])
+end
+local ranking = 0
+if ARGV[5] ~= "" then
+	if ARGV[4] ~= "0" then
+		local score = redis.call("ZSCORE", KEYS[3], ARGV[5])
+		if score then
+			ranking = tie_rank(KEYS[3], ARGV[1], score, ARGV[4], ARGV[6])
+		end
+	else
+		local rank
+		if ARGV[1] == "1" then
+			rank = redis.call("ZREVRANK", KEYS[3], ARGV[5])
+		else
+			rank = redis.call("ZRANK", KEYS[3], ARGV[5])
+		end
+		if rank then
+			ranking = rank + 1
+		end
+	end
+end
+redis.call("DEL", KEYS[3])
+return {start, first, items, fetch_data(KEYS[4], items), ranking}
+`)
+
+// the keys of read scripts, with the data key appended if WithData
+func (this *RankList) _readKeys(keys ...string) []string {
+	if this.withData {
+		keys = append(keys, this._dataKey())
+	}
+	return keys
+}
+
+func (this *RankList) _desc(ordering ...orderings.Ordering) int {
+	if this.Order.Or(ordering...) == OrderingDesc {
+		return 1
+	}
+	return 0
+}
+
+// the errors of scripts using tie_rank
+func tieRankErr(err error) error {
+	if err != nil && strings.Contains(err.Error(), "DENSE_RANK_LIMIT") {
+		return ErrDenseRankLimit
+	}
+	return err
+}
+
+func (this *RankList) _getTieRanking(member string) (int64, error) {
+	c := context.TODO()
+	keys := []string{this.key}
+	ranking, err := luaRankListTieRanking.Run(c, this.redis, keys, this._desc(), member, int(this.tie), MaxDenseRanking).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return ranking, tieRankErr(err)
+}
+
+// GetListWithRank is like GetList but returns the ranking of each member following the tie policy.
+// With a reversed ordering, the rankings are counted in that ordering.
+func (this *RankList) GetListWithRank(start int, count int, ordering ...orderings.Ordering) ([]RankItem, error) {
+	items, _, err := this._getPage(start, count, ordering...)
+	return items, err
+}
+
+// returns the items and their stored scores
+func (this *RankList) _getPage(start int, count int, ordering ...orderings.Ordering) ([]RankItem, []float64, error) {
+	end := start + count - 1
+	if start < 0 || start > end {
+		return nil, nil, fmt.Errorf("invalid params: start(%d) > end(%d)", start, end)
+	}
+	rs, err := this._runPage(start, count, this.tie, ordering...)
+	if err != nil || rs == nil {
+		return nil, nil, err
+	}
+	return this._parseRankItems(rs)
+}
+
+// runs luaRankListPage, the first ranking is start+1 with TieOrdinal. Returns nil if nothing to read.
+func (this *RankList) _runPage(start int, count int, tie TiePolicy, ordering ...orderings.Ordering) ([]interface{}, error) {
+	c := context.TODO()
+	keys := this._readKeys(this.key)
+	end := start + count - 1
+	rs, err := luaRankListPage.Run(c, this.redis, keys, this._desc(ordering...), start, end, int(tie), MaxDenseRanking).Slice()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, tieRankErr(err)
+	}
+	return rs, nil
+}
+
+func (this *RankList) GetTopWithRank(count int, ordering ...orderings.Ordering) ([]RankItem, error) {
+	return this.GetListWithRank(0, count, ordering...)
+}
+
+// GetListWithin returns the ranked members which are also in set, e.g. the friends of a player,
+// and the ranking of member within the set (0 if absent or member is empty).
+// The intersection is stored in a temporary key and removed in the same script.
+func (this *RankList) GetListWithin(set *Set, start int, count int, member string, ordering ...orderings.Ordering) ([]RankItem, int64, error) {
+	if start < 0 || count < 0 {
+		return nil, 0, fmt.Errorf("invalid params: start(%d) count(%d)", start, count)
+	}
+	c := context.TODO()
+	keys := this._readKeys(this.key, set.key, this.key+":tmp:within")
+	end := start + count - 1
+	rs, err := luaRankListWithin.Run(c, this.redis, keys, this._desc(ordering...), start, end, int(this.tie), member, MaxDenseRanking).Slice()
+	if err != nil {
+		return nil, 0, tieRankErr(err)
+	}
+	items, _, err := this._parseRankItems(rs)
+	if err != nil {
+		return nil, 0, err
+	}
+	ranking, _ := rs[4].(int64)
+	return items, ranking, nil
+}
+
+// GetRankingWithin returns the ranking of member among the members of set, 0 if absent.
+func (this *RankList) GetRankingWithin(set *Set, member string) (int64, error) {
+	_, ranking, err := this.GetListWithin(set, 0, 0, member)
+	return ranking, err
+}
+
+// GetAround returns the member with up to `above` better ranked and `below` worse ranked members in one round trip.
+// Returns nil if the member is not in the list.
+func (this *RankList) GetAround(member string, above int, below int, ordering ...orderings.Ordering) ([]RankItem, error) {
+	if above < 0 || below < 0 {
+		return nil, fmt.Errorf("invalid params: above(%d) below(%d)", above, below)
+	}
+	c := context.TODO()
+	keys := this._readKeys(this.key)
+	rs, err := luaRankListAround.Run(c, this.redis, keys, this._desc(ordering...), member, above, below, int(this.tie), MaxDenseRanking).Slice()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, tieRankErr(err)
+	}
+	items, _, err := this._parseRankItems(rs)
+	return items, err
+}
+
+// parse {start, first ranking, {member1, score1, ...}, {data1, ...}} replied by the scripts,
+// returns the items and their stored scores
+func (this *RankList) _parseRankItems(rs []interface{}) ([]RankItem, []float64, error) {
+	if err := this.CheckEncoder(); err != nil {
+		return nil, nil, err
+	}
+	start, _ := rs[0].(int64)
+	first, _ := rs[1].(int64)
+	flat, _ := rs[2].([]interface{})
+	items := make([]RankItem, 0, len(flat)/2)
+	raws := make([]float64, 0, len(flat)/2)
+	var rank int64
+	var prev string
+	for i := 0; i+1 < len(flat); i += 2 {
+		member, _ := flat[i].(string)
+		scoreStr, _ := flat[i+1].(string)
+		score, err := strconv.ParseFloat(scoreStr, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid member: %s with score: %s", member, scoreStr)
+		}
+		idx := int64(i / 2)
+		switch {
+		case idx == 0:
+			rank = first
+		case this.tie == TieOrdinal:
+			rank = start + idx + 1
+		case scoreStr == prev:
+			// same ranking
+		case this.tie == TieDense:
+			rank++
+		default:
+			rank = start + idx + 1
+		}
+		prev = scoreStr
+		raws = append(raws, score)
+		if this._encoder() != nil {
+			score = this._decodeScore(score)
+		}
+		items = append(items, RankItem{
+			Member: member,
+			Score:  score,
+			Rank:   rank,
+		})
+	}
+	if data, _ := rs[3].([]interface{}); len(data) > 0 {
+		if err := this._parseData(items, data); err != nil {
+			return nil, nil, err
+		}
+	}
+	return items, raws, nil
+}
@@ -0,0 +1,136 @@
+package redisobj
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cupen/redisobj/encoders"
+	"github.com/redis/go-redis/v9"
+)
+
+const metaFieldEncoder = "encoder"
+
+// the encoder check state, shared by the clones with the same key and encoder
+type rankMeta struct {
+	mu       sync.Mutex
+	checked  bool           // compared with the stored one
+	stored   bool           // stored if absent
+	detected encoders.Score // the stored encoder if none is set
+}
+
+func (this *RankList) _metaKey() string {
+	return this.key + ":meta"
+}
+
+// a new meta for a new key or encoder, the clones with the old ones keep theirs
+func (this *RankList) _newMeta() {
+	this.meta = &rankMeta{}
+}
+
+// resets the meta shared by the clones, e.g. after Clear, so that all of them check the encoder again
+func (this *RankList) _resetMeta() {
+	if this.meta == nil {
+		this._newMeta()
+		return
+	}
+	this.meta.mu.Lock()
+	defer this.meta.mu.Unlock()
+	this.meta.checked, this.meta.stored, this.meta.detected = false, false, nil
+}
+
+// the encoder set by WithEncoder, or the one detected from the stored spec
+func (this *RankList) _encoder() encoders.Score {
+	if this.enc != nil || this.meta == nil {
+		return this.enc
+	}
+	this.meta.mu.Lock()
+	defer this.meta.mu.Unlock()
+	return this.meta.detected
+}
+
+// GetStoredEncoder returns the encoder spec stored alongside the list, nil if none.
+func (this *RankList) GetStoredEncoder() (*encoders.Spec, error) {
+	c := context.TODO()
+	raw, err := this.redis.HGet(c, this._metaKey(), metaFieldEncoder).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var spec encoders.Spec
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		return nil, fmt.Errorf("invalid encoder of %s: %s", this.key, raw)
+	}
+	return &spec, nil
+}
+
+// CheckEncoder compares the encoder with the stored one now instead of on the first use, see WithEncoder.
+// Without an encoder, the stored one is detected.
+func (this *RankList) CheckEncoder() error {
+	return this._checkEncoder(false)
+}
+
+// the writes store the encoder if absent, the reads only check it so that no meta key is left for a missing list.
+func (this *RankList) _checkEncoder(write bool) error {
+	if this.meta == nil {
+		this._newMeta()
+	}
+	meta := this.meta
+	meta.mu.Lock()
+	defer meta.mu.Unlock()
+	if meta.stored || (meta.checked && !write) {
+		return nil
+	}
+
+	var spec encoders.Spec
+	if this.enc != nil {
+		var err error
+		spec, err = encoders.SpecOf(this.enc)
+		if err != nil {
+			if errors.Is(err, encoders.ErrNotDescribable) {
+				// custom encoders are not checked
+				meta.checked, meta.stored = true, true
+				return nil
+			}
+			return err
+		}
+	}
+	if write && this.enc != nil {
+		raw, err := json.Marshal(spec)
+		if err != nil {
+			return err
+		}
+		c := context.TODO()
+		ok, err := this.redis.HSetNX(c, this._metaKey(), metaFieldEncoder, string(raw)).Result()
+		if err != nil {
+			return err
+		}
+		if ok {
+			meta.checked, meta.stored = true, true
+			return nil
+		}
+	}
+
+	stored, err := this.GetStoredEncoder()
+	if err != nil {
+		return err
+	}
+	switch {
+	case stored == nil:
+	case this.enc == nil:
+		enc, err := encoders.New(*stored)
+		if err != nil {
+			return fmt.Errorf("%w: %s is encoded by %s: %w", ErrEncoderMismatch, this.key, stored, err)
+		}
+		meta.detected = enc
+	case !stored.Equal(spec):
+		return fmt.Errorf("%w: %s is encoded by %s, not %s", ErrEncoderMismatch, this.key, stored, spec)
+	}
+	meta.checked = true
+	meta.stored = write
+	return nil
+}
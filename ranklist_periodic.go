@@ -0,0 +1,224 @@
+package redisobj
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cupen/redisobj/periods"
+)
+
+var (
+	ErrNoPeriod = errors.New("no period")
+)
+
+// PeriodicRankList writes to the ranklist of the current period, e.g. daily, weekly or seasonal boards.
+// The key of each period is "baseKey:<period id>".
+type PeriodicRankList struct {
+	rank      *RankList
+	period    periods.Period
+	loc       *time.Location
+	now       func() time.Time
+	retention time.Duration
+
+	mu        sync.Mutex
+	current   *RankList // the ranklist of the last written period, so its encoder check is done once
+	currentID string
+	expired   bool // the TTL of the current period is set
+}
+
+// NewPeriodicRankList uses rank as the template of every period, the ordering, encoder and others are kept.
+func NewPeriodicRankList(rank *RankList, period periods.Period, loc *time.Location) *PeriodicRankList {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &PeriodicRankList{
+		rank:   rank,
+		period: period,
+		loc:    loc,
+		now:    time.Now,
+	}
+}
+
+func (this *PeriodicRankList) WithClock(now func() time.Time) *PeriodicRankList {
+	this.now = now
+	return this
+}
+
+// WithRetention keeps each period for the duration after it ends, 0 means forever.
+// The TTL is set by the first write of each period.
+func (this *PeriodicRankList) WithRetention(retention time.Duration) *PeriodicRankList {
+	this.retention = retention
+	return this
+}
+
+func (this *PeriodicRankList) startOf(t time.Time) (time.Time, error) {
+	start := this.period.Start(t.In(this.loc))
+	if start.IsZero() {
+		return start, ErrNoPeriod
+	}
+	return start, nil
+}
+
+// CurrentID returns the id of the current period.
+func (this *PeriodicRankList) CurrentID() (string, error) {
+	start, err := this.startOf(this.now())
+	if err != nil {
+		return "", err
+	}
+	return this.period.ID(start), nil
+}
+
+// At returns the ranklist of the period containing t.
+func (this *PeriodicRankList) At(t time.Time) (*RankList, error) {
+	start, err := this.startOf(t)
+	if err != nil {
+		return nil, err
+	}
+	return this._rankOf(start), nil
+}
+
+// the ranklist of the period starting at start, sharing the encoder check of the last written one
+func (this *PeriodicRankList) _rankOf(start time.Time) *RankList {
+	id := this.period.ID(start)
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.current != nil && this.currentID == id {
+		return this.current.Clone()
+	}
+	return this.rank.WithID(id)
+}
+
+// the ranklist of the period to write, cached until the period changes.
+// expire is true until the TTL of the period is set.
+func (this *PeriodicRankList) _writeRank(start time.Time) (rank *RankList, expire bool) {
+	id := this.period.ID(start)
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.current == nil || this.currentID != id {
+		this.current, this.currentID, this.expired = this.rank.WithID(id), id, false
+	}
+	return this.current, this.retention > 0 && !this.expired
+}
+
+// writes to the current period, and sets its TTL after the first write of the period
+func (this *PeriodicRankList) _write(write func(*RankList) (bool, error)) error {
+	start, err := this.startOf(this.now())
+	if err != nil {
+		return err
+	}
+	rank, expire := this._writeRank(start)
+	written, err := write(rank)
+	if err != nil || !written || !expire {
+		return err
+	}
+	end := this.period.Next(start)
+	if end.IsZero() {
+		return nil
+	}
+	if err := rank.SetTTLAt(end.Add(this.retention)); err != nil {
+		return err
+	}
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if this.current == rank {
+		this.expired = true
+	}
+	return nil
+}
+
+func (this *PeriodicRankList) Current() (*RankList, error) {
+	return this.At(this.now())
+}
+
+// Previous returns the ranklist of the n-th finished period, Previous(1) is the last one.
+func (this *PeriodicRankList) Previous(n int) (*RankList, error) {
+	start, err := this.startOf(this.now())
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		start = this.period.Prev(start)
+		if start.IsZero() {
+			return nil, ErrNoPeriod
+		}
+	}
+	return this._rankOf(start), nil
+}
+
+// Set writes to the current period, and sets its TTL to the end of the period plus the retention
+// on the first write of the period.
+func (this *PeriodicRankList) Set(member string, score float64, factor int32) (int64, error) {
+	var rs int64
+	err := this._write(func(rank *RankList) (bool, error) {
+		var err error
+		rs, err = rank.Set(member, score, factor)
+		return true, err
+	})
+	return rs, err
+}
+
+func (this *PeriodicRankList) SetWithData(member string, score float64, factor int32, data Dict) (int64, error) {
+	var rs int64
+	err := this._write(func(rank *RankList) (bool, error) {
+		var err error
+		rs, err = rank.SetWithData(member, score, factor, data)
+		return true, err
+	})
+	return rs, err
+}
+
+// the conditional writes of the current period, the TTL is set once one is done
+func (this *PeriodicRankList) _setIf(set func(*RankList) (bool, error)) (bool, error) {
+	var ok bool
+	err := this._write(func(rank *RankList) (bool, error) {
+		var err error
+		ok, err = set(rank)
+		return ok, err
+	})
+	return ok, err
+}
+
+func (this *PeriodicRankList) SetIfGreater(member string, score float64, factor int32) (bool, error) {
+	return this._setIf(func(rank *RankList) (bool, error) {
+		return rank.SetIfGreater(member, score, factor)
+	})
+}
+
+func (this *PeriodicRankList) SetIfLess(member string, score float64, factor int32) (bool, error) {
+	return this._setIf(func(rank *RankList) (bool, error) {
+		return rank.SetIfLess(member, score, factor)
+	})
+}
+
+func (this *PeriodicRankList) AddIfAbsent(member string, score float64, factor int32) (bool, error) {
+	return this._setIf(func(rank *RankList) (bool, error) {
+		return rank.AddIfAbsent(member, score, factor)
+	})
+}
+
+func (this *PeriodicRankList) UpdateOnly(member string, score float64, factor int32) (bool, error) {
+	return this._setIf(func(rank *RankList) (bool, error) {
+		return rank.UpdateOnly(member, score, factor)
+	})
+}
+
+func (this *PeriodicRankList) IncrScore(member string, delta float64, factor int32) (float64, error) {
+	var score float64
+	err := this._write(func(rank *RankList) (bool, error) {
+		var err error
+		score, err = rank.IncrScore(member, delta, factor)
+		return true, err
+	})
+	return score, err
+}
+
+func (this *PeriodicRankList) SetBatch(entries []RankEntry) (errs []error, err error) {
+	err = this._write(func(rank *RankList) (bool, error) {
+		var err error
+		errs, err = rank.SetBatch(entries)
+		return slices.Contains(errs, nil), err
+	})
+	return errs, err
+}
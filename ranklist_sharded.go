@@ -0,0 +1,462 @@
+package redisobj
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cupen/redisobj/encoders"
+	"github.com/cupen/redisobj/orderings"
+	"github.com/redis/go-redis/v9"
+)
+
+// ShardedRankList partitions the members across N ranklists by the hash of member,
+// the shard i is stored at "<key>:<i>".
+// GetList orders equal stored scores by member as a single zset does, GetRanking follows the tie policy
+// of the template.
+type ShardedRankList struct {
+	rank   *RankList
+	shards []*RankList
+}
+
+// NewShardedRankList uses rank as the template of every shard, the ordering, encoder and others are kept.
+func NewShardedRankList(rank *RankList, shards int) *ShardedRankList {
+	if shards <= 0 {
+		panic(fmt.Errorf("invalid shards: %d", shards))
+	}
+	this := &ShardedRankList{rank: rank}
+	this._build(shards)
+	return this
+}
+
+func (this *ShardedRankList) _build(count int) {
+	this.shards = make([]*RankList, count)
+	for i := range this.shards {
+		shard := this.rank.Clone()
+		shard.key = this.rank.key + ":" + strconv.Itoa(i)
+		shard._newMeta()
+		this.shards[i] = shard
+	}
+}
+
+func (this *ShardedRankList) WithID(rankId string) *ShardedRankList {
+	cloned := &ShardedRankList{rank: this.rank.WithID(rankId)}
+	cloned._build(len(this.shards))
+	return cloned
+}
+
+func (this *ShardedRankList) WithEncoder(enc encoders.Score) *ShardedRankList {
+	this.rank.WithEncoder(enc)
+	for _, shard := range this.shards {
+		shard.WithEncoder(enc)
+	}
+	return this
+}
+
+func (this *ShardedRankList) GetEncoder() encoders.Score {
+	return this.rank._encoder()
+}
+
+func (this *ShardedRankList) Shards() []*RankList {
+	return this.shards
+}
+
+// Shard returns the shard of member.
+func (this *ShardedRankList) Shard(member string) *RankList {
+	return this.shards[this._shardOf(member)]
+}
+
+func (this *ShardedRankList) _shardOf(member string) int {
+	h := fnv.New32a()
+	h.Write([]byte(member))
+	return int(h.Sum32() % uint32(len(this.shards)))
+}
+
+func (this *ShardedRankList) Set(member string, score float64, factor int32) (int64, error) {
+	return this.Shard(member).Set(member, score, factor)
+}
+
+func (this *ShardedRankList) SetIfGreater(member string, score float64, factor int32) (bool, error) {
+	return this.Shard(member).SetIfGreater(member, score, factor)
+}
+
+func (this *ShardedRankList) SetIfLess(member string, score float64, factor int32) (bool, error) {
+	return this.Shard(member).SetIfLess(member, score, factor)
+}
+
+func (this *ShardedRankList) AddIfAbsent(member string, score float64, factor int32) (bool, error) {
+	return this.Shard(member).AddIfAbsent(member, score, factor)
+}
+
+func (this *ShardedRankList) UpdateOnly(member string, score float64, factor int32) (bool, error) {
+	return this.Shard(member).UpdateOnly(member, score, factor)
+}
+
+func (this *ShardedRankList) SetWithData(member string, score float64, factor int32, data Dict) (int64, error) {
+	return this.Shard(member).SetWithData(member, score, factor, data)
+}
+
+// SetBatch sets the entries of each shard in one round trip, errs[i] is the error of entries[i].
+// err is the first error of the round trips, the other shards are written anyway.
+func (this *ShardedRankList) SetBatch(entries []RankEntry) (errs []error, err error) {
+	errs = make([]error, len(entries))
+	groups := make([][]int, len(this.shards))
+	for i, e := range entries {
+		j := this._shardOf(e.Member)
+		groups[j] = append(groups[j], i)
+	}
+	for j, indexes := range groups {
+		if len(indexes) <= 0 {
+			continue
+		}
+		batch := make([]RankEntry, len(indexes))
+		for k, i := range indexes {
+			batch[k] = entries[i]
+		}
+		shardErrs, shardErr := this.shards[j].SetBatch(batch)
+		for k, i := range indexes {
+			errs[i] = shardErrs[k]
+		}
+		if shardErr != nil && err == nil {
+			err = shardErr
+		}
+	}
+	return errs, err
+}
+
+func (this *ShardedRankList) IncrScore(member string, delta float64, factor int32) (float64, error) {
+	return this.Shard(member).IncrScore(member, delta, factor)
+}
+
+func (this *ShardedRankList) Delete(member string) (bool, error) {
+	return this.Shard(member).Delete(member)
+}
+
+func (this *ShardedRankList) GetScore(member string) (float64, error) {
+	return this.Shard(member).GetScore(member)
+}
+
+// the ranking of a score across the shards, KEYS: shards...; ARGV: desc(1/0), score, member, policy, dense limit
+// With TieOrdinal, equal scores are ordered by member bytes as ZRANGE does. With TieDense, the distinct
+// better scores of every shard are walked up to the limit.
+var luaShardedRanking = redis.NewScript(`
+local desc = ARGV[1] == "1"
+local score = ARGV[2]
+if ARGV[4] == "2" then
+	local scores, count = {}, 0
+	for _, key in ipairs(KEYS) do
+		local cur = score
+		while true do
+			local items
+			if desc then
+				items = redis.call("ZRANGE", key, "(" .. cur, "+inf", "BYSCORE", "LIMIT", 0, 1, "WITHSCORES")
+			else
+				items = redis.call("ZRANGE", key, "(" .. cur, "-inf", "BYSCORE", "REV", "LIMIT", 0, 1, "WITHSCORES")
+			end
+			if #items == 0 then
+				break
+			end
+			cur = items[2]
+			if not scores[cur] then
+				scores[cur] = true
+				count = count + 1
+				if count >= tonumber(ARGV[5]) then
+					error("DENSE_RANK_LIMIT")
+				end
+			end
+		end
+	end
+	return count + 1
+end
+
+-- compare bytes, the "<" of lua follows the locale
+local function less(a, b)
+	for i = 1, math.min(#a, #b) do
+		local x, y = string.byte(a, i), string.byte(b, i)
+		if x ~= y then
+			return x < y
+		end
+	end
+	return #a < #b
+end
+-- the count of equal scores ranked before member, binary searched by index as they are ordered by member
+local function ties(key)
+	local member = ARGV[3]
+	local lo = redis.call("ZCOUNT", key, "-inf", "(" .. score)
+	local run = redis.call("ZCOUNT", key, score, score)
+	local l, h = lo, lo + run
+	while l < h do
+		local mid = math.floor((l + h) / 2)
+		local m = redis.call("ZRANGE", key, mid, mid)[1]
+		if less(m, member) or (desc and m == member) then
+			l = mid + 1
+		else
+			h = mid
+		end
+	end
+	if desc then
+		return lo + run - l
+	end
+	return l - lo
+end
+
+local n = 1
+for _, key in ipairs(KEYS) do
+	if desc then
+		n = n + redis.call("ZCOUNT", key, "(" .. score, "+inf")
+	else
+		n = n + redis.call("ZCOUNT", key, "-inf", "(" .. score)
+	end
+	if ARGV[4] == "0" then
+		n = n + ties(key)
+	end
+end
+return n
+`)
+
+// the keys of all shards
+func (this *ShardedRankList) _keys() []string {
+	keys := make([]string, len(this.shards))
+	for i, shard := range this.shards {
+		keys[i] = shard.key
+	}
+	return keys
+}
+
+// GetRanking ranks member across all shards by the tie policy of the template in one script, 0 if absent.
+// With TieOrdinal, equal scores are ordered by member as in GetList. With TieDense, the distinct
+// better scores of every shard are walked as RankList does.
+func (this *ShardedRankList) GetRanking(member string) (int64, error) {
+	c := context.TODO()
+	score, err := this.rank.redis.ZScore(c, this.Shard(member).key, member).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return this._ranking(member, score, this.rank.tie, this.rank._desc())
+}
+
+// the ranking of member with the stored score across the shards
+func (this *ShardedRankList) _ranking(member string, score float64, tie TiePolicy, desc int) (int64, error) {
+	c := context.TODO()
+	args := []interface{}{desc, score, member, int(tie), MaxDenseRanking}
+	ranking, err := luaShardedRanking.Run(c, this.rank.redis, this._keys(), args...).Int64()
+	return ranking, tieRankErr(err)
+}
+
+// GetList merges the top start+count of each shard, the ordering defaults to the template's ordering.
+func (this *ShardedRankList) GetList(start int, count int, ordering ...orderings.Ordering) ([]redis.Z, error) {
+	list, decoder, err := this._getList(start, count, ordering...)
+	if err != nil {
+		return nil, err
+	}
+	if decoder != nil {
+		decoder._decodeScores(list)
+	}
+	return list, nil
+}
+
+// returns the merged list with the stored scores, and the shard to decode them with
+func (this *ShardedRankList) _getList(start int, count int, ordering ...orderings.Ordering) ([]redis.Z, *RankList, error) {
+	end := start + count - 1
+	if start < 0 || start > end {
+		return nil, nil, fmt.Errorf("invalid params: start(%d) > end(%d)", start, end)
+	}
+	decoder, err := this._checkEncoder()
+	if err != nil {
+		return nil, nil, err
+	}
+	desc := this.rank.Order.Or(ordering...) == OrderingDesc
+	c := context.TODO()
+	cmds := make([]*redis.ZSliceCmd, len(this.shards))
+	_, err = this.rank.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for i, shard := range this.shards {
+			if desc {
+				cmds[i] = pipe.ZRevRangeWithScores(c, shard.key, 0, int64(end))
+			} else {
+				cmds[i] = pipe.ZRangeWithScores(c, shard.key, 0, int64(end))
+			}
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, nil, err
+	}
+
+	merged := make([]redis.Z, 0, len(this.shards)*count)
+	for _, cmd := range cmds {
+		merged = append(merged, cmd.Val()...)
+	}
+	// the same order as a single zset: by score, then by member
+	sort.Slice(merged, func(i, j int) bool {
+		a, b := merged[i], merged[j]
+		if a.Score != b.Score {
+			return (a.Score > b.Score) == desc
+		}
+		return (a.Member.(string) > b.Member.(string)) == desc
+	})
+	if start >= len(merged) {
+		return nil, decoder, nil
+	}
+	return merged[start:min(end+1, len(merged))], decoder, nil
+}
+
+// GetListWithRank is like GetList but returns the ranking of each member following the tie policy of the template,
+// the ranking of the first member is counted across the shards. The data is fetched if WithData.
+func (this *ShardedRankList) GetListWithRank(start int, count int, ordering ...orderings.Ordering) ([]RankItem, error) {
+	list, decoder, err := this._getList(start, count, ordering...)
+	if err != nil || len(list) <= 0 {
+		return nil, err
+	}
+	tie := this.rank.tie
+	items := make([]RankItem, 0, len(list))
+	var rank int64
+	for i, z := range list {
+		member, _ := z.Member.(string)
+		switch {
+		case i == 0 && tie != TieOrdinal:
+			rank, err = this._ranking(member, z.Score, tie, this.rank._desc(ordering...))
+			if err != nil {
+				return nil, err
+			}
+		case i == 0, tie == TieOrdinal:
+			rank = int64(start + i + 1)
+		case z.Score == list[i-1].Score:
+			// same ranking
+		case tie == TieDense:
+			rank++
+		default:
+			rank = int64(start + i + 1)
+		}
+		score := z.Score
+		if decoder != nil {
+			score = decoder._decodeScore(score)
+		}
+		items = append(items, RankItem{Member: member, Score: score, Rank: rank})
+	}
+	if this.rank.withData {
+		if err := this._fetchData(items); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func (this *ShardedRankList) GetTopWithRank(count int, ordering ...orderings.Ordering) ([]RankItem, error) {
+	return this.GetListWithRank(0, count, ordering...)
+}
+
+// GetAround returns the member with up to `above` better ranked and `below` worse ranked members across the shards.
+// Returns nil if the member is not in the list.
+func (this *ShardedRankList) GetAround(member string, above int, below int, ordering ...orderings.Ordering) ([]RankItem, error) {
+	if above < 0 || below < 0 {
+		return nil, fmt.Errorf("invalid params: above(%d) below(%d)", above, below)
+	}
+	c := context.TODO()
+	score, err := this.rank.redis.ZScore(c, this.Shard(member).key, member).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// the position in the merged list
+	pos, err := this._ranking(member, score, TieOrdinal, this.rank._desc(ordering...))
+	if err != nil {
+		return nil, err
+	}
+	start := max(0, int(pos)-1-above)
+	return this.GetListWithRank(start, int(pos)-start+below, ordering...)
+}
+
+// fetches the data of items from the shard of each member
+func (this *ShardedRankList) _fetchData(items []RankItem) error {
+	c := context.TODO()
+	cmds := make([]*redis.StringCmd, len(items))
+	_, err := this.rank.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for i, item := range items {
+			cmds[i] = pipe.HGet(c, this.Shard(item.Member)._dataKey(), item.Member)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	raws := make([]interface{}, len(cmds))
+	for i, cmd := range cmds {
+		if cmd.Err() == nil {
+			raws[i] = cmd.Val()
+		}
+	}
+	return this.rank._parseData(items, raws)
+}
+
+// checks the encoder of every shard, each once, and returns a shard with an encoder to decode with,
+// nil if none. Without an encoder, the one stored alongside the shards is detected.
+func (this *ShardedRankList) _checkEncoder() (*RankList, error) {
+	var decoder *RankList
+	for _, shard := range this.shards {
+		if err := shard.CheckEncoder(); err != nil {
+			return nil, err
+		}
+		if decoder == nil && shard._encoder() != nil {
+			decoder = shard
+		}
+	}
+	return decoder, nil
+}
+
+func (this *ShardedRankList) GetTop(count int, ordering ...orderings.Ordering) ([]redis.Z, error) {
+	return this.GetList(0, count, ordering...)
+}
+
+func (this *ShardedRankList) Size() (int64, error) {
+	c := context.TODO()
+	cmds := make([]*redis.IntCmd, len(this.shards))
+	_, err := this.rank.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for i, shard := range this.shards {
+			cmds[i] = pipe.ZCard(c, shard.key)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	var size int64
+	for _, cmd := range cmds {
+		size += cmd.Val()
+	}
+	return size, nil
+}
+
+func (this *ShardedRankList) Clear() error {
+	for _, shard := range this.shards {
+		if err := shard.Clear(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *ShardedRankList) SetTTL(ttl time.Duration) error {
+	for _, shard := range this.shards {
+		if err := shard.SetTTL(ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *ShardedRankList) SetTTLAt(ts time.Time) error {
+	for _, shard := range this.shards {
+		if err := shard.SetTTLAt(ts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
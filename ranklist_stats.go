@@ -0,0 +1,147 @@
+package redisobj
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/cupen/redisobj/encoders"
+	"github.com/redis/go-redis/v9"
+)
+
+// ARGV: desc(1/0), percentile
+var luaRankListScoreAtPercentile = redis.NewScript(`
+local n = redis.call("ZCARD", KEYS[1])
+if n == 0 then
+	return false
+end
+local r = n - math.ceil(tonumber(ARGV[2]) * n / 100)
+if r < 1 then
+	r = 1
+end
+local items
+if ARGV[1] == "1" then
+	items = redis.call("ZREVRANGE", KEYS[1], r - 1, r - 1, "WITHSCORES")
+else
+	items = redis.call("ZRANGE", KEYS[1], r - 1, r - 1, "WITHSCORES")
+end
+return items[2]
+`)
+
+// the lowest stored score of the given score, so bounds match the encoded scores.
+func (this *RankList) _encodeBound(score float64) float64 {
+	enc := this._encoder()
+	if enc == nil {
+		return score
+	}
+	score = math.Ceil(score)
+	if enc64, ok := enc.(encoders.Score64); ok {
+		minScore, maxScore := enc64.ScoreRange()
+		if score > float64(maxScore) {
+			return math.Inf(1)
+		}
+		if score < float64(minScore) {
+			return math.Inf(-1)
+		}
+		lo, _ := enc64.EncodeInt64(int64(score), 0)
+		hi, _ := enc64.EncodeInt64(int64(score), enc64.MaxFactor())
+		return float64(min(lo, hi))
+	}
+	if score > math.MaxInt32 {
+		return math.Inf(1)
+	}
+	if score < math.MinInt32 {
+		return math.Inf(-1)
+	}
+	lo := enc.Encode(int32(score), 0)
+	hi := enc.Encode(int32(score), math.MaxInt32)
+	return float64(min(lo, hi))
+}
+
+// Percentile returns the percentage of members ranked below member, in [0, 100).
+// A member in the top 3% has a percentile >= 97. Returns ErrNil if member is absent.
+func (this *RankList) Percentile(member string) (float64, error) {
+	key := this.key
+	c := context.TODO()
+	var rankCmd *redis.IntCmd
+	var sizeCmd *redis.IntCmd
+	_, err := this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		if this.Order == OrderingDesc {
+			rankCmd = pipe.ZRevRank(c, key, member)
+		} else {
+			rankCmd = pipe.ZRank(c, key, member)
+		}
+		sizeCmd = pipe.ZCard(c, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	size := sizeCmd.Val()
+	below := size - (rankCmd.Val() + 1)
+	return 100 * float64(below) / float64(size), nil
+}
+
+// ScoreAtPercentile returns the score of the lowest ranked member whose percentile is >= p.
+// Returns 0 if the list is empty.
+func (this *RankList) ScoreAtPercentile(p float64) (float64, error) {
+	if p < 0 || p > 100 {
+		return 0, fmt.Errorf("invalid percentile: %f", p)
+	}
+	if err := this.CheckEncoder(); err != nil {
+		return 0, err
+	}
+	c := context.TODO()
+	keys := []string{this.key}
+	score, err := luaRankListScoreAtPercentile.Run(c, this.redis, keys, this._desc(), p).Float64()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if this._encoder() != nil {
+		score = this._decodeScore(score)
+	}
+	return score, nil
+}
+
+// Histogram counts the members by score in len(boundaries)+1 buckets:
+// (-inf, b0), [b0, b1), ..., [bn, +inf). The boundaries must be ascending.
+func (this *RankList) Histogram(boundaries []float64) ([]int64, error) {
+	if !sort.Float64sAreSorted(boundaries) {
+		return nil, fmt.Errorf("invalid params: boundaries must be ascending")
+	}
+	if err := this.CheckEncoder(); err != nil {
+		return nil, err
+	}
+	bounds := make([]ScoreBound, 0, len(boundaries)+2)
+	bounds = append(bounds, ScoreNegInf)
+	for _, b := range boundaries {
+		bounds = append(bounds, ScoreIncl(this._encodeBound(b)))
+	}
+	bounds = append(bounds, ScorePosInf)
+
+	key := this.key
+	c := context.TODO()
+	cmds := make([]*redis.IntCmd, 0, len(bounds)-1)
+	_, err := this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for i := 0; i+1 < len(bounds); i++ {
+			max := bounds[i+1]
+			if max != ScorePosInf {
+				max = "(" + max
+			}
+			cmds = append(cmds, pipe.ZCount(c, key, string(bounds[i]), string(max)))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	counts := make([]int64, len(cmds))
+	for i, cmd := range cmds {
+		counts[i] = cmd.Val()
+	}
+	return counts, nil
+}
@@ -1,13 +1,20 @@
 package redisobj
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"slices"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/cupen/redisobj/encoders"
+	"github.com/cupen/redisobj/orderings"
+	"github.com/cupen/redisobj/periods"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/stretchr/testify/assert"
@@ -226,6 +233,12 @@ func TestRankList_WithEncoder(t *testing.T) {
 	score6, err := rank2.GetScore("id6")
 	assert.NoError(t, err)
 	assert.Equal(t, float64(6), score6)
+
+	// not stored as 0
+	_, err = rank.Set("id7", -1, 0)
+	assert.ErrorIs(t, err, encoders.ErrScoreOverflow)
+	_, err = rank.Set("id7", math.MaxInt32+1, 0)
+	assert.ErrorIs(t, err, encoders.ErrScoreOverflow)
 }
 
 func TestRankList_WithEncoder_Mixed(t *testing.T) {
@@ -359,3 +372,1027 @@ func TestRankList_WithCond(t *testing.T) {
 		}
 	})
 }
+
+func TestRankList_SetIfGreater(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_SetIfGreater", "desc")
+	rank.Clear()
+
+	ok, err := rank.SetIfGreater("id1", 10, 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = rank.SetIfGreater("id1", 5, 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = rank.SetIfGreater("id1", 20, 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = rank.AddIfAbsent("id1", 1, 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = rank.UpdateOnly("id2", 1, 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	score, err := rank.GetScore("id1")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(20), score)
+}
+
+func TestRankList_IncrScore(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_IncrScore", "desc")
+	rank.Clear()
+	rank = rank.WithEncoder(encoders.FirstInIsBigger)
+
+	score, err := rank.IncrScore("id1", 3, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(3), score)
+
+	score, err = rank.IncrScore("id1", 4, 200)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(7), score)
+
+	score, err = rank.GetScore("id1")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(7), score)
+}
+
+func TestRankList_WithCapacity(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_WithCapacity", "desc")
+	rank.Clear()
+	rank = rank.WithCapacity(3)
+
+	for i := 1; i <= 3; i++ {
+		evicted, err := rank.SetWithLimit(fmt.Sprintf("id%d", i), float64(i), 0)
+		assert.NoError(t, err)
+		assert.Empty(t, evicted)
+	}
+	evicted, err := rank.SetWithLimit("id4", 4, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id1"}, evicted)
+
+	for i := 5; i <= 100; i++ {
+		rank.Set(fmt.Sprintf("id%d", i), float64(i), 0)
+	}
+	size, err := rank.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), size)
+
+	items, err := rank.GetTop(10)
+	assert.NoError(t, err)
+	if assert.Equal(t, 3, len(items)) {
+		assert.Equal(t, "id100", items[0].Member)
+		assert.Equal(t, "id98", items[2].Member)
+	}
+}
+
+func TestRankList_WithCapacity_CondWrites(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_WithCapacity_CondWrites", "desc")
+	rank.Clear()
+	rank = rank.WithCapacity(3)
+
+	for i := 1; i <= 3; i++ {
+		_, err := rank.Set(fmt.Sprintf("id%d", i), float64(i), 0)
+		assert.NoError(t, err)
+	}
+	ok, err := rank.SetIfGreater("id4", 4, 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = rank.AddIfAbsent("id5", 5, 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = rank.SetIfLess("id6", 6, 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = rank.UpdateOnly("id6", 7, 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	score, err := rank.IncrScore("id7", 8, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(8), score)
+
+	size, err := rank.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), size)
+	items, err := rank.GetTop(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id7", "id6", "id5"}, zsetMembers(items))
+
+	// the encoded increment is trimmed in the same transaction
+	encoded := rank.WithID("encoded").WithEncoder(encoders.FirstInIsBigger)
+	t.Cleanup(func() { encoded.Clear() })
+	for i := 1; i <= 4; i++ {
+		_, err := encoded.IncrScore(fmt.Sprintf("id%d", i), float64(i), int32(i))
+		assert.NoError(t, err)
+	}
+	size, err = encoded.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), size)
+	ranking, err := encoded.GetRanking("id1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), ranking)
+}
+
+func zsetMembers(items []redis.Z) []string {
+	members := make([]string, 0, len(items))
+	for _, item := range items {
+		members = append(members, item.Member.(string))
+	}
+	return members
+}
+
+func TestRankList_GetAround(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_GetAround", "desc")
+	rank.Clear()
+	for i := 1; i <= 20; i++ {
+		rank.Set(fmt.Sprintf("id%d", i), float64(i), 0)
+	}
+
+	t.Run("middle", func(t *testing.T) {
+		items, err := rank.GetAround("id10", 2, 3)
+		assert.NoError(t, err)
+		if assert.Equal(t, 6, len(items)) {
+			assert.Equal(t, RankItem{Member: "id12", Score: 12, Rank: 9}, items[0])
+			assert.Equal(t, RankItem{Member: "id10", Score: 10, Rank: 11}, items[2])
+			assert.Equal(t, RankItem{Member: "id7", Score: 7, Rank: 14}, items[5])
+		}
+	})
+
+	t.Run("clamp", func(t *testing.T) {
+		items, err := rank.GetAround("id19", 5, 0)
+		assert.NoError(t, err)
+		if assert.Equal(t, 2, len(items)) {
+			assert.Equal(t, int64(1), items[0].Rank)
+			assert.Equal(t, "id19", items[1].Member)
+		}
+
+		items, err = rank.GetAround("id2", 0, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(items))
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		items, err := rank.GetAround("none", 5, 5)
+		assert.NoError(t, err)
+		assert.Nil(t, items)
+	})
+}
+
+func TestRankList_WithTiePolicy(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_WithTiePolicy", "desc")
+	rank.Clear()
+	rank.Set("id1", 10, 0)
+	rank.Set("id2", 8, 0)
+	rank.Set("id3", 8, 0)
+	rank.Set("id4", 5, 0)
+
+	tests := []struct {
+		tie   TiePolicy
+		ranks []int64
+	}{
+		{TieOrdinal, []int64{1, 2, 3, 4}},
+		{TieCompetition, []int64{1, 2, 2, 4}},
+		{TieDense, []int64{1, 2, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(int(tt.tie)), func(t *testing.T) {
+			_rank := rank.WithTiePolicy(tt.tie)
+			items, err := _rank.GetTopWithRank(10)
+			assert.NoError(t, err)
+			if assert.Equal(t, 4, len(items)) {
+				for i, item := range items {
+					assert.Equal(t, tt.ranks[i], item.Rank)
+				}
+			}
+
+			items, err = _rank.GetListWithRank(2, 2)
+			assert.NoError(t, err)
+			if assert.Equal(t, 2, len(items)) {
+				assert.Equal(t, tt.ranks[2], items[0].Rank)
+				assert.Equal(t, tt.ranks[3], items[1].Rank)
+			}
+
+			ranking, err := _rank.GetRanking("id4")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.ranks[3], ranking)
+		})
+	}
+}
+
+func TestRankList_UnionInto(t *testing.T) {
+	s1 := newTestObj(t, "prefiex_test_UnionInto_s1", "desc")
+	s2 := newTestObj(t, "prefiex_test_UnionInto_s2", "desc")
+	dst := newTestObj(t, "prefiex_test_UnionInto_dst", "desc")
+	s1.Set("id1", 1, 0)
+	s1.Set("id2", 2, 0)
+	s2.Set("id2", 3, 0)
+
+	size, err := s1.UnionInto(dst, []*RankList{s2}, nil, AggregateSum, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), size)
+	score, err := dst.GetScore("id2")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(5), score)
+
+	size, err = s1.IntersectInto(dst, []*RankList{s2}, []float64{1, 10}, AggregateMax, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), size)
+	score, err = dst.GetScore("id2")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(30), score)
+
+	t.Run("encoded", func(t *testing.T) {
+		enc := encoders.LastInIsBigger
+		_, err := s1.Clone().WithEncoder(enc).UnionInto(dst, []*RankList{s2}, nil, AggregateMax, 0)
+		assert.ErrorIs(t, err, ErrEncoderMismatch)
+
+		e1 := s1.Clone().WithEncoder(enc)
+		e2 := s2.Clone().WithEncoder(enc)
+		eDst := dst.Clone().WithEncoder(enc)
+		_, err = e1.UnionInto(eDst, []*RankList{e2}, nil, AggregateSum, 0)
+		assert.ErrorIs(t, err, ErrEncodedAggregate)
+	})
+}
+
+func TestRankList_GetListWithin(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_GetListWithin", "desc")
+	friends := NewSet(rank.redis, "prefiex_test_GetListWithin_friends")
+	t.Cleanup(func() {
+		friends.Clear()
+	})
+	for i := 1; i <= 10; i++ {
+		rank.Set(fmt.Sprintf("id%d", i), float64(i), 0)
+	}
+	friends.Add("id2", "id5", "id9", "none")
+
+	items, ranking, err := rank.GetListWithin(&friends, 0, 10, "id5")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), ranking)
+	if assert.Equal(t, 3, len(items)) {
+		assert.Equal(t, RankItem{Member: "id9", Score: 9, Rank: 1}, items[0])
+		assert.Equal(t, RankItem{Member: "id5", Score: 5, Rank: 2}, items[1])
+		assert.Equal(t, RankItem{Member: "id2", Score: 2, Rank: 3}, items[2])
+	}
+
+	ranking, err = rank.GetRankingWithin(&friends, "id1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), ranking)
+}
+
+func TestRankList_WithData(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_WithData", "desc").WithData()
+	rank.Clear()
+	t.Cleanup(func() {
+		rank.Clear()
+	})
+
+	for i := 1; i <= 5; i++ {
+		id := fmt.Sprintf("id%d", i)
+		_, err := rank.SetWithData(id, float64(i), 0, Dict{"name": "user" + strconv.Itoa(i)})
+		assert.NoError(t, err)
+	}
+	rank.Set("id6", 6, 0)
+
+	items, err := rank.GetTopWithRank(3)
+	assert.NoError(t, err)
+	if assert.Equal(t, 3, len(items)) {
+		assert.Nil(t, items[0].Data)
+		assert.Equal(t, Dict{"name": "user5"}, items[1].Data)
+	}
+
+	items, err = rank.GetAround("id3", 1, 1)
+	assert.NoError(t, err)
+	if assert.Equal(t, 3, len(items)) {
+		assert.Equal(t, Dict{"name": "user3"}, items[1].Data)
+	}
+
+	rank.Delete("id5")
+	data, err := rank.GetData("id5")
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+
+	rank.DeleteByRanking(1, 2) // id6, id4
+	data, err = rank.GetData("id4")
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+
+	data, err = rank.GetData("id3")
+	assert.NoError(t, err)
+	assert.Equal(t, Dict{"name": "user3"}, data)
+}
+
+func TestRankList_SetTTL(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_SetTTL", "desc").WithData().WithRankTracking(RankTracking{})
+	rank.Clear()
+	_, err := rank.SetWithData("id1", 1, 0, Dict{"name": "a"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, rank.SetTTL(time.Minute))
+	c := context.TODO()
+	for _, key := range []string{rank.key, rank._dataKey(), rank._ranksKey()} {
+		ttl, err := rank.redis.TTL(c, key).Result()
+		assert.NoError(t, err)
+		assert.Greater(t, ttl, time.Duration(0), key)
+	}
+
+	assert.NoError(t, rank.SetTTLAt(time.Now().Add(-time.Minute)))
+	n, err := rank.redis.Exists(c, rank.key, rank._dataKey(), rank._ranksKey()).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+}
+
+func TestRankList_Percentile(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_Percentile", "desc")
+	rank.Clear()
+	rank = rank.WithEncoder(encoders.LastInIsBigger)
+	for i := 1; i <= 100; i++ {
+		rank.Set(fmt.Sprintf("id%d", i), float64(i), int32(i))
+	}
+
+	p, err := rank.Percentile("id100")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(99), p)
+
+	p, err = rank.Percentile("id1")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), p)
+
+	_, err = rank.Percentile("none")
+	assert.Equal(t, ErrNil, err)
+
+	score, err := rank.ScoreAtPercentile(90)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(91), score)
+
+	counts, err := rank.Histogram([]float64{10, 50, 90.5})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{9, 40, 41, 10}, counts)
+}
+
+func TestRankList_Export(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_Export", "desc").WithEncoder(encoders.FirstInIsBigger)
+	rank.Clear()
+	for i := 1; i <= 2500; i++ {
+		rank.Set(fmt.Sprintf("id%d", i), float64(i%100), int32(i))
+	}
+	snapshot, err := rank.Snapshot("final", time.Minute)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		snapshot.Clear()
+	})
+	rank.Set("id1", 1000, 0)
+
+	for _, format := range []ExportFormat{ExportCSV, ExportJSONL} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			err := snapshot.Export(&buf, format)
+			assert.NoError(t, err)
+
+			restored := newTestObj(t, "prefiex_test_Export_restored", "desc").WithEncoder(encoders.FirstInIsBigger)
+			restored.Clear()
+			count, err := restored.Import(&buf, format)
+			assert.NoError(t, err)
+			assert.Equal(t, 2500, count)
+
+			expected, err := snapshot.GetTopWithRank(3000)
+			assert.NoError(t, err)
+			actual, err := restored.GetTopWithRank(3000)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
+func TestRankList_Export_TieDense(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_Export_TieDense", "desc").WithTiePolicy(TieDense)
+	rank.Clear()
+	limit := MaxDenseRanking
+	MaxDenseRanking = 100
+	t.Cleanup(func() { MaxDenseRanking = limit })
+
+	elems := make([]redis.Z, 0, 2500)
+	for i := 0; i < 2500; i++ {
+		elems = append(elems, redis.Z{Member: "id" + strconv.Itoa(i), Score: float64(i % 300)})
+	}
+	assert.NoError(t, rank.redis.ZAdd(context.TODO(), rank.key, elems...).Err())
+
+	// the rankings past the first page are carried, not counted by Redis
+	var buf bytes.Buffer
+	assert.NoError(t, rank.Export(&buf, ExportJSONL))
+	dec := json.NewDecoder(&buf)
+	var prev rankRecord
+	for i := 0; ; i++ {
+		var rec rankRecord
+		if err := dec.Decode(&rec); err != nil {
+			assert.Equal(t, 2500, i)
+			break
+		}
+		assert.Equal(t, int64(300-rec.Raw), rec.Rank, rec.Member)
+		assert.GreaterOrEqual(t, rec.Rank, prev.Rank)
+		prev = rec
+	}
+	assert.Equal(t, int64(300), prev.Rank)
+}
+
+func TestRankList_Snapshot_Missing(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_Snapshot_Missing", "desc").WithEncoder(encoders.FirstInIsBigger)
+	rank.Clear()
+	rank.Set("id1", 1, 0)
+	snapshot, err := rank.Snapshot("final", time.Minute)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		snapshot.Clear()
+	})
+
+	// a snapshot of a missing list is empty, not the previous one
+	assert.NoError(t, rank.Clear())
+	snapshot, err = rank.Snapshot("final", time.Minute)
+	assert.NoError(t, err)
+	size, err := snapshot.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), size)
+	spec, err := snapshot.GetStoredEncoder()
+	assert.NoError(t, err)
+	assert.Nil(t, spec)
+}
+
+func TestRankList_Import(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_Import", "desc").WithEncoder(encoders.FirstInIsBigger).WithCapacity(2)
+	rank.Clear()
+	input := "{\"member\":\"id1\",\"raw\":1}\n{\"member\":\"id2\",\"raw\":2}\n{\"member\":\"id3\",\"raw\":3}\n"
+	count, err := rank.Import(strings.NewReader(input), ExportJSONL)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	spec, err := rank.GetStoredEncoder()
+	assert.NoError(t, err)
+	assert.NotNil(t, spec)
+	items, err := rank.redis.ZRevRange(context.TODO(), rank.key, 0, -1).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id3", "id2"}, items)
+}
+
+func TestRankList_WithRankTracking(t *testing.T) {
+	stream := "prefiex_test_WithRankTracking_events"
+	rank := newTestObj(t, "prefiex_test_WithRankTracking", "desc").WithRankTracking(RankTracking{
+		Thresholds: []int64{1, 3},
+		Stream:     stream,
+	})
+	rank.Clear()
+	rank.redis.Del(context.TODO(), stream)
+	t.Cleanup(func() {
+		rank.redis.Del(context.TODO(), stream)
+	})
+
+	for i := 1; i <= 5; i++ {
+		rank.Set(fmt.Sprintf("id%d", i), float64(i*10), 0)
+	}
+	rank.redis.Del(context.TODO(), stream)
+	rank.Set("id1", 100, 0)
+
+	delta, err := rank.GetRankDelta("id1")
+	assert.NoError(t, err)
+	assert.Equal(t, RankDelta{Rank: 1, Prev: 5, Best: 1, Delta: 4}, delta)
+
+	msgs, err := rank.redis.XRange(context.TODO(), stream, "-", "+").Result()
+	assert.NoError(t, err)
+	events := make([]RankEvent, 0, len(msgs))
+	for _, msg := range msgs {
+		event, err := ParseRankEvent(msg.Values["event"].(string))
+		assert.NoError(t, err)
+		events = append(events, event)
+	}
+	assert.Equal(t, []RankEvent{
+		{Type: RankEventEnter, Member: "id1", Threshold: 1, Rank: 1, Prev: 5},
+		{Type: RankEventLeave, Member: "id5", Threshold: 1, Rank: 2, Prev: 1, By: "id1"},
+		{Type: RankEventEnter, Member: "id1", Threshold: 3, Rank: 1, Prev: 5},
+		{Type: RankEventLeave, Member: "id3", Threshold: 3, Rank: 4, Prev: 3, By: "id1"},
+	}, events)
+}
+
+func TestRankList_WithRankTracking_CondWrites(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_WithRankTracking_CondWrites", "desc").WithRankTracking(RankTracking{
+		Thresholds: []int64{1},
+	})
+	rank.Clear()
+	for i := 1; i <= 3; i++ {
+		rank.Set(fmt.Sprintf("id%d", i), float64(i*10), 0)
+	}
+
+	ok, err := rank.SetIfGreater("id1", 25, 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	delta, err := rank.GetRankDelta("id1")
+	assert.NoError(t, err)
+	assert.Equal(t, RankDelta{Rank: 2, Prev: 3, Best: 1, Delta: 1}, delta)
+
+	// a skipped write keeps the last delta
+	ok, err = rank.SetIfGreater("id1", 5, 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	delta, err = rank.GetRankDelta("id1")
+	assert.NoError(t, err)
+	assert.Equal(t, RankDelta{Rank: 2, Prev: 3, Best: 1, Delta: 1}, delta)
+
+	score, err := rank.IncrScore("id1", 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(35), score)
+	delta, err = rank.GetRankDelta("id1")
+	assert.NoError(t, err)
+	assert.Equal(t, RankDelta{Rank: 1, Prev: 2, Best: 1, Delta: 1}, delta)
+
+	encoded := rank.WithID("encoded").WithEncoder(encoders.FirstInIsBigger)
+	t.Cleanup(func() { encoded.Clear() })
+	encoded.IncrScore("id1", 10, 1)
+	encoded.IncrScore("id2", 20, 2)
+	encoded.IncrScore("id1", 15, 3)
+	delta, err = encoded.GetRankDelta("id1")
+	assert.NoError(t, err)
+	assert.Equal(t, RankDelta{Rank: 1, Prev: 2, Best: 1, Delta: 1}, delta)
+}
+
+func TestRankList_SetBatch(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_SetBatch", "desc").WithEncoder(encoders.LastInIsBigger)
+	rank.Clear()
+	rank = rank.WithCond(func(id string) bool {
+		return id != "banned"
+	})
+
+	errs, err := rank.SetBatch([]RankEntry{
+		{"id1", 1, 1},
+		{"banned", 2, 1},
+		{"id3", math.MaxInt32 + 1, 1},
+		{"id4", 4, 1},
+	})
+	assert.NoError(t, err)
+	if assert.Equal(t, 4, len(errs)) {
+		assert.NoError(t, errs[0])
+		assert.Equal(t, ErrCondFalse, errs[1])
+		assert.Error(t, errs[2])
+		assert.NoError(t, errs[3])
+	}
+
+	items, err := rank.GetTop(10)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(items)) {
+		assert.Equal(t, redis.Z{Member: "id4", Score: 4}, items[0])
+		assert.Equal(t, redis.Z{Member: "id1", Score: 1}, items[1])
+	}
+}
+
+func TestRankList_SetBatch_Tracked(t *testing.T) {
+	stream := "prefiex_test_SetBatch_Tracked_events"
+	rank := newTestObj(t, "prefiex_test_SetBatch_Tracked", "desc").WithRankTracking(RankTracking{
+		Thresholds: []int64{1},
+		Stream:     stream,
+	})
+	rank.Clear()
+	c := context.TODO()
+	t.Cleanup(func() {
+		rank.redis.Del(c, stream)
+	})
+
+	// the script is loaded on NOSCRIPT
+	assert.NoError(t, rank.redis.ScriptFlush(c).Err())
+	errs, err := rank.SetBatch([]RankEntry{{"id1", 1, 0}, {"id2", 2, 0}})
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil, nil}, errs)
+	delta, err := rank.GetRankDelta("id2")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), delta.Rank)
+
+	// the script errors are reported per entry
+	rank.redis.Del(c, stream)
+	rank.redis.Set(c, stream, "not a stream", 0)
+	errs, err = rank.SetBatch([]RankEntry{{"id3", 3, 0}, {"id4", 4, 0}})
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(errs)) {
+		assert.Error(t, errs[0])
+		assert.Error(t, errs[1])
+	}
+}
+
+func TestShardedRankList(t *testing.T) {
+	rank := NewShardedRankList(newTestObj(t, "prefiex_test_ShardedRankList", "desc"), 4)
+	rank.Clear()
+	t.Cleanup(func() {
+		rank.Clear()
+	})
+	for i := 1; i <= 100; i++ {
+		rank.Set(fmt.Sprintf("id%d", i), float64(i), 0)
+	}
+
+	size, err := rank.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), size)
+
+	items, err := rank.GetList(5, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{
+		{Member: "id95", Score: 95},
+		{Member: "id94", Score: 94},
+		{Member: "id93", Score: 93},
+	}, items)
+
+	ranking, err := rank.GetRanking("id90")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), ranking)
+}
+
+func TestShardedRankList_API(t *testing.T) {
+	template := newTestObj(t, "prefiex_test_ShardedRankList_API", "desc").WithData().WithTiePolicy(TieCompetition)
+	rank := NewShardedRankList(template, 4)
+	rank.Clear()
+	t.Cleanup(func() {
+		rank.Clear()
+	})
+
+	entries := make([]RankEntry, 0, 10)
+	for i := 1; i <= 10; i++ {
+		entries = append(entries, RankEntry{Member: fmt.Sprintf("id%d", i), Score: float64(i / 2)})
+	}
+	errs, err := rank.SetBatch(entries)
+	assert.NoError(t, err)
+	assert.Equal(t, make([]error, 10), errs)
+	_, err = rank.SetWithData("top", 10, 0, Dict{"name": "top"})
+	assert.NoError(t, err)
+
+	ok, err := rank.SetIfGreater("id1", 0, 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = rank.SetIfLess("id1", -1, 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = rank.AddIfAbsent("id1", 9, 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = rank.UpdateOnly("none", 9, 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// top 10, id10 5, id8 id9 4, id6 id7 3, id4 id5 2, id2 id3 1, id1 -1
+	items, err := rank.GetListWithRank(1, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, []RankItem{
+		{Member: "id10", Score: 5, Rank: 2},
+		{Member: "id9", Score: 4, Rank: 3},
+		{Member: "id8", Score: 4, Rank: 3},
+		{Member: "id7", Score: 3, Rank: 5},
+	}, items)
+	items, err = rank.GetTopWithRank(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []RankItem{{Member: "top", Score: 10, Rank: 1, Data: Dict{"name": "top"}}}, items)
+
+	items, err = rank.GetAround("id6", 1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []RankItem{
+		{Member: "id7", Score: 3, Rank: 5},
+		{Member: "id6", Score: 3, Rank: 5},
+		{Member: "id5", Score: 2, Rank: 7},
+	}, items)
+	items, err = rank.GetAround("id1", 1, 1, OrderingAsc)
+	assert.NoError(t, err)
+	assert.Equal(t, []RankItem{
+		{Member: "id1", Score: -1, Rank: 1},
+		{Member: "id2", Score: 1, Rank: 2},
+	}, items)
+	items, err = rank.GetAround("none", 1, 1)
+	assert.NoError(t, err)
+	assert.Nil(t, items)
+
+	assert.NoError(t, rank.SetTTLAt(time.Now().Add(time.Minute)))
+	for _, shard := range rank.Shards() {
+		ttl, err := shard.redis.TTL(context.TODO(), shard.key).Result()
+		assert.NoError(t, err)
+		assert.Greater(t, ttl, time.Duration(0))
+	}
+}
+
+func TestShardedRankList_DetectEncoder(t *testing.T) {
+	template := newTestObj(t, "prefiex_test_ShardedRankList_detect", "desc")
+	rank := NewShardedRankList(template.Clone().WithEncoder(encoders.FirstInIsBigger), 4)
+	rank.Clear()
+	t.Cleanup(func() {
+		rank.Clear()
+	})
+	for i := 1; i <= 10; i++ {
+		_, err := rank.Set(fmt.Sprintf("id%d", i), float64(i), int32(i))
+		assert.NoError(t, err)
+	}
+
+	detected := NewShardedRankList(template, 4)
+	items, err := detected.GetTop(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Member: "id10", Score: 10}, {Member: "id9", Score: 9}}, items)
+}
+
+func TestShardedRankList_WithTiePolicy(t *testing.T) {
+	template := newTestObj(t, "prefiex_test_ShardedRankList_tie", "desc")
+	rank := NewShardedRankList(template, 4)
+	rank.Clear()
+	t.Cleanup(func() {
+		rank.Clear()
+	})
+	// the members spread over the shards: 30, 20 x 8, 10 x 8
+	rank.Set("top", 30, 0)
+	for i := 1; i <= 8; i++ {
+		rank.Set(fmt.Sprintf("a%d", i), 20, 0)
+		rank.Set(fmt.Sprintf("b%d", i), 10, 0)
+	}
+	items, err := rank.GetList(0, 20)
+	assert.NoError(t, err)
+
+	expected := map[TiePolicy]func(i int) int64{
+		TieOrdinal:     func(i int) int64 { return int64(i + 1) },
+		TieCompetition: func(i int) int64 { return map[float64]int64{30: 1, 20: 2, 10: 10}[items[i].Score] },
+		TieDense:       func(i int) int64 { return map[float64]int64{30: 1, 20: 2, 10: 3}[items[i].Score] },
+	}
+	for tie, ranking := range expected {
+		sharded := NewShardedRankList(template.WithTiePolicy(tie), 4)
+		for i, item := range items {
+			actual, err := sharded.GetRanking(item.Member.(string))
+			assert.NoError(t, err)
+			assert.Equal(t, ranking(i), actual, "tie %d, member %s", tie, item.Member)
+		}
+	}
+
+	// the equal scores are ordered by member in both orderings
+	asc := NewShardedRankList(template.WithOrder(orderings.Asc), 4)
+	items, err = asc.GetList(0, 20)
+	assert.NoError(t, err)
+	for i, item := range items {
+		actual, err := asc.GetRanking(item.Member.(string))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(i+1), actual, "member %s", item.Member)
+	}
+}
+
+func TestRankList_WithEncoder_Bits(t *testing.T) {
+	enc := encoders.MustBits(encoders.BitsConfig{ScoreBits: 34, FactorBits: 19, Signed: true})
+	rank := newTestObj(t, "prefiex_test_WithEncoder_Bits", "desc").WithEncoder(enc)
+	rank.Clear()
+
+	_, err := rank.Set("big", math.MaxInt32+10, 1)
+	assert.NoError(t, err)
+	_, err = rank.Set("negative", -5, 1)
+	assert.NoError(t, err)
+	_, err = rank.Set("zero", 0, 1)
+	assert.NoError(t, err)
+	_, err = rank.Set("overflow", 1<<40, 1)
+	assert.ErrorIs(t, err, encoders.ErrScoreOverflow)
+	_, err = rank.Set("factor", 1, 1<<20)
+	assert.ErrorIs(t, err, encoders.ErrFactorOverflow)
+
+	items, err := rank.GetTop(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{
+		{Member: "big", Score: math.MaxInt32 + 10},
+		{Member: "zero", Score: 0},
+		{Member: "negative", Score: -5},
+	}, items)
+
+	score, err := rank.GetScoreByRanking(2)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(-5), score)
+}
+
+func TestRankList_WithEncoder_Timestamp(t *testing.T) {
+	enc := encoders.EarlierWins(time.Now().Add(-time.Hour), time.Millisecond)
+	rank := newTestObj(t, "prefiex_test_WithEncoder_Timestamp", "desc").WithEncoder(enc)
+	rank.Clear()
+
+	rank.Set("first", 10, 0)
+	time.Sleep(5 * time.Millisecond)
+	rank.Set("second", 10, 0)
+
+	items, err := rank.GetTop(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{
+		{Member: "first", Score: 10},
+		{Member: "second", Score: 10},
+	}, items)
+}
+
+func TestRankList_SetFields(t *testing.T) {
+	enc := encoders.MustComposite(
+		encoders.Field{Name: "wins", Bits: 16},
+		encoders.Field{Name: "goals", Bits: 12, Signed: true},
+		encoders.Field{Name: "finish", Bits: 25, Reverse: true},
+	)
+	rank := newTestObj(t, "prefiex_test_SetFields", "desc").WithEncoder(enc)
+	rank.Clear()
+
+	type result struct {
+		Wins     int
+		GoalDiff int `rank:"goals"`
+		Finish   int
+	}
+	_, err := rank.SetFields("a", 3, -1, 200)
+	assert.NoError(t, err)
+	_, err = rank.SetFields("b", 3, -1, 100)
+	assert.NoError(t, err)
+	_, err = rank.SetStruct("c", result{Wins: 3, GoalDiff: 2, Finish: 300})
+	assert.NoError(t, err)
+	_, err = rank.SetFields("d", 3, 1<<20, 100)
+	assert.ErrorIs(t, err, encoders.ErrScoreOverflow)
+
+	items, err := rank.GetTop(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{
+		{Member: "c", Score: 3},
+		{Member: "b", Score: 3},
+		{Member: "a", Score: 3},
+	}, items)
+
+	values, err := rank.GetFields("a")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, -1, 200}, values)
+
+	var got result
+	assert.NoError(t, rank.GetStruct("c", &got))
+	assert.Equal(t, result{Wins: 3, GoalDiff: 2, Finish: 300}, got)
+
+	_, err = rank.GetFields("absent")
+	assert.Equal(t, ErrNil, err)
+}
+
+func TestRankList_EncoderMeta(t *testing.T) {
+	enc := encoders.MustBits(encoders.BitsConfig{ScoreBits: 32, FactorBits: 21, Signed: true})
+	rank := newTestObj(t, "prefiex_test_EncoderMeta", "desc").WithEncoder(enc)
+	rank.Clear()
+
+	_, err := rank.Set("a", -3, 1)
+	assert.NoError(t, err)
+	spec, err := rank.GetStoredEncoder()
+	assert.NoError(t, err)
+	if assert.NotNil(t, spec) {
+		assert.Equal(t, "bits", spec.Name)
+	}
+
+	// the same encoder built elsewhere
+	same := rank.Clone().WithEncoder(encoders.MustBits(encoders.BitsConfig{ScoreBits: 32, FactorBits: 21, Signed: true}))
+	score, err := same.GetScore("a")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(-3), score)
+
+	other := rank.Clone().WithEncoder(encoders.LastInIsBigger)
+	_, err = other.GetList(0, 10)
+	assert.ErrorIs(t, err, ErrEncoderMismatch)
+	_, err = other.Set("b", 1, 0)
+	assert.ErrorIs(t, err, ErrEncoderMismatch)
+
+	detected := rank.Clone().WithEncoder(nil)
+	dense := detected.WithTiePolicy(TieDense)
+	items, err := detected.GetTop(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Member: "a", Score: -3}}, items)
+	assert.True(t, encoders.Equal(enc, detected.GetEncoder()))
+
+	// the clones share the detected encoder
+	items, err = dense.GetTop(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Member: "a", Score: -3}}, items)
+}
+
+func TestRankList_EncoderMeta_Clear(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_EncoderMeta_Clear", "desc").WithEncoder(encoders.FirstInIsBigger)
+	rank.Clear()
+	capped := rank.WithCapacity(10)
+	_, err := capped.Set("a", 1, 1)
+	assert.NoError(t, err)
+
+	// the clones see the Clear, and store the encoder again
+	assert.NoError(t, rank.Clear())
+	_, err = capped.Set("a", 1, 1)
+	assert.NoError(t, err)
+	spec, err := rank.GetStoredEncoder()
+	assert.NoError(t, err)
+	assert.NotNil(t, spec)
+}
+
+func TestRankList_WithOrder(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_WithOrder", "desc").WithOrder(orderings.Asc)
+	rank.Clear()
+	assert.Equal(t, OrderingAsc, rank.Order)
+
+	rank.Set("id1", 1, 0)
+	rank.Set("id2", 2, 0)
+	rank.Set("id3", 3, 0)
+
+	items, err := rank.GetTop(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Member: "id1", Score: 1}, {Member: "id2", Score: 2}}, items)
+
+	items, err = rank.GetTop(2, rank.Order.Reverse())
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Member: "id3", Score: 3}, {Member: "id2", Score: 2}}, items)
+
+	ranked, err := rank.GetListWithRank(0, 1, orderings.Desc)
+	assert.NoError(t, err)
+	assert.Equal(t, []RankItem{{Member: "id3", Score: 3, Rank: 1}}, ranked)
+}
+
+func TestPeriodicRankList(t *testing.T) {
+	now := time.Now().UTC()
+	rank := newTestObj(t, "prefiex_test_Periodic", "desc").WithEncoder(encoders.LastInIsBigger)
+	daily := NewPeriodicRankList(rank, periods.Day, time.UTC).
+		WithClock(func() time.Time { return now }).
+		WithRetention(72 * time.Hour)
+	firstID := periods.Day.ID(periods.Day.Start(now))
+
+	_, err := daily.Set("id1", 1, 0)
+	assert.NoError(t, err)
+	written := daily.current
+	_, err = daily.Set("id2", 2, 0)
+	assert.NoError(t, err)
+	// the ranklist and its encoder check are reused within the period
+	assert.Same(t, written, daily.current)
+	assert.True(t, written.meta.stored)
+
+	now = now.Add(24 * time.Hour)
+	_, err = daily.Set("id3", 3, 0)
+	assert.NoError(t, err)
+	assert.NotSame(t, written, daily.current)
+
+	prev, err := daily.Previous(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "prefiex_test_Periodic:"+firstID, prev.FullKey())
+	items, err := prev.GetTop(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Member: "id2", Score: 2}, {Member: "id1", Score: 1}}, items)
+	t.Cleanup(func() {
+		prev.Clear()
+		cur, _ := daily.Current()
+		cur.Clear()
+	})
+}
+
+func TestPeriodicRankList_Writes(t *testing.T) {
+	now := time.Now().UTC()
+	daily := NewPeriodicRankList(newTestObj(t, "prefiex_test_Periodic_Writes", "desc"), periods.Day, time.UTC).
+		WithClock(func() time.Time { return now }).
+		WithRetention(time.Hour)
+	c := context.TODO()
+	ttlOf := func() time.Duration {
+		cur, err := daily.Current()
+		assert.NoError(t, err)
+		ttl, err := cur.redis.TTL(c, cur.key).Result()
+		assert.NoError(t, err)
+		return ttl
+	}
+	t.Cleanup(func() {
+		prev, _ := daily.Previous(1)
+		prev.Clear()
+		cur, _ := daily.Current()
+		cur.Clear()
+	})
+
+	ok, err := daily.UpdateOnly("id1", 1, 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, daily.expired)
+	ok, err = daily.SetIfGreater("id1", 1, 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Greater(t, ttlOf(), time.Duration(0))
+
+	// the TTL is set once per period, not on every write
+	cur, _ := daily.Current()
+	assert.NoError(t, cur.redis.Persist(c, cur.key).Err())
+	score, err := daily.IncrScore("id1", 2, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(3), score)
+	assert.Equal(t, time.Duration(-1), ttlOf())
+
+	now = now.Add(24 * time.Hour)
+	errs, err := daily.SetBatch([]RankEntry{{Member: "id1", Score: 1}, {Member: "id2", Score: 2}})
+	assert.NoError(t, err)
+	assert.Equal(t, make([]error, 2), errs)
+	assert.Greater(t, ttlOf(), time.Duration(0))
+}
+
+func TestRankList_TieDenseLimit(t *testing.T) {
+	rank := newTestObj(t, "prefiex_test_TieDenseLimit", "desc").WithTiePolicy(TieDense)
+	rank.Clear()
+	limit := MaxDenseRanking
+	MaxDenseRanking = 100
+	t.Cleanup(func() { MaxDenseRanking = limit })
+
+	elems := make([]redis.Z, 0, MaxDenseRanking+1)
+	for i := 0; i <= MaxDenseRanking; i++ {
+		elems = append(elems, redis.Z{Member: "id" + strconv.Itoa(i), Score: float64(i)})
+	}
+	assert.NoError(t, rank.redis.ZAdd(context.TODO(), rank.key, elems...).Err())
+
+	ranking, err := rank.GetRanking("id1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(MaxDenseRanking), ranking)
+
+	_, err = rank.GetRanking("id0")
+	assert.ErrorIs(t, err, ErrDenseRankLimit)
+	_, err = rank.GetListWithRank(MaxDenseRanking, 1)
+	assert.ErrorIs(t, err, ErrDenseRankLimit)
+}
@@ -0,0 +1,217 @@
+package redisobj
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// set the score with the ZADD flags, trim to max members and record the rankings of member in "prev:best",
+// then publish the events of the thresholds crossed. Nothing is recorded if a conditional write was skipped.
+// KEYS: zset, stream (or any key if unused), ranks hash, [other companion hashes...]
+// ARGV: desc(1/0), max, member, score, channel, use stream(1/0), stream maxlen, flags separated by spaces,
+// threshold1, ...
+// returns {the reply of ZADD, evicted members}
+var luaRankListTrackedSet = redis.NewScript(luaTrimFunc + `
+local desc = ARGV[1] == "1"
+local member = ARGV[3]
+local function rank_of(m)
+	local r
+	if desc then
+		r = redis.call("ZREVRANK", KEYS[1], m)
+	else
+		r = redis.call("ZRANK", KEYS[1], m)
+	end
+	if r then
+		return r + 1
+	end
+	return false
+end
+local function member_at(rank)
+	local items
+	if desc then
+		items = redis.call("ZREVRANGE", KEYS[1], rank - 1, rank - 1)
+	else
+		items = redis.call("ZRANGE", KEYS[1], rank - 1, rank - 1)
+	end
+	return items[1]
+end
+local function emit(event)
+	local payload = cjson.encode(event)
+	if ARGV[5] ~= "" then
+		redis.call("PUBLISH", ARGV[5], payload)
+	end
+	if ARGV[6] == "1" then
+		redis.call("XADD", KEYS[2], "MAXLEN", "~", ARGV[7], "*", "event", payload)
+	end
+end
+
+local old = rank_of(member)
+local zadd = {KEYS[1]}
+for flag in string.gmatch(ARGV[8], "%S+") do
+	zadd[#zadd + 1] = flag
+end
+zadd[#zadd + 1] = ARGV[4]
+zadd[#zadd + 1] = member
+local added = redis.call("ZADD", unpack(zadd))
+if ARGV[8] ~= "" and (not added or added == 0) then
+	return {added, {}}
+end
+
+local evicted = trim(KEYS[1], tonumber(ARGV[2]), desc, {unpack(KEYS, 3)})
+
+local new = rank_of(member)
+if not new then
+	return {added, evicted}
+end
+local best = new
+local state = redis.call("HGET", KEYS[3], member)
+if state then
+	local b = tonumber(string.match(state, ":(%d+)$"))
+	if b and b > 0 and b < best then
+		best = b
+	end
+end
+redis.call("HSET", KEYS[3], member, (old or 0) .. ":" .. best)
+
+for i = 9, #ARGV do
+	local t = tonumber(ARGV[i])
+	local was_in = old and old <= t
+	local is_in = new <= t
+	if is_in and not was_in then
+		emit({type = "enter", member = member, threshold = t, rank = new, prev = old or 0})
+		local overtaken = member_at(t + 1)
+		if overtaken then
+			emit({type = "leave", member = overtaken, threshold = t, rank = t + 1, prev = t, by = member})
+		end
+	elseif was_in and not is_in then
+		emit({type = "leave", member = member, threshold = t, rank = new, prev = old})
+		local promoted = member_at(t)
+		if promoted and promoted ~= member then
+			emit({type = "enter", member = promoted, threshold = t, rank = t, prev = t + 1, by = member})
+		end
+	end
+end
+return {added, evicted}
+`)
+
+const defaultRankEventsMaxLen = 10000
+
+// RankTracking records the previous and the best ranking of each member written by Set,
+// and publishes a RankEvent when a member enters or leaves the top of a threshold.
+type RankTracking struct {
+	Thresholds   []int64 // e.g. 1, 10, 100
+	Channel      string  // pub/sub channel, empty to disable
+	Stream       string  // stream key, empty to disable
+	StreamMaxLen int64   // approximate, 10000 if <= 0
+}
+
+const (
+	RankEventEnter = "enter"
+	RankEventLeave = "leave"
+)
+
+type RankEvent struct {
+	Type      string `json:"type"`
+	Member    string `json:"member"`
+	Threshold int64  `json:"threshold"`
+	Rank      int64  `json:"rank"`
+	Prev      int64  `json:"prev"`
+	By        string `json:"by,omitempty"` // the member who overtook or was overtaken by this one
+}
+
+// ParseRankEvent parses the payload of a pub/sub message or the "event" field of a stream entry.
+func ParseRankEvent(payload string) (RankEvent, error) {
+	var event RankEvent
+	err := json.Unmarshal([]byte(payload), &event)
+	return event, err
+}
+
+type RankDelta struct {
+	Rank  int64 // the current ranking, 0 if absent
+	Prev  int64 // the ranking before the last write, 0 if it was absent
+	Best  int64
+	Delta int64 // Prev - Rank, positive means climbed
+}
+
+// WithRankTracking enables the rank tracking on every write of a member, e.g. Set, SetIfGreater, IncrScore,
+// SetWithData and SetBatch. The rankings are stored in the companion hash "key:ranks".
+// The bulk writes Import, UnionInto and IntersectInto are not tracked.
+func (this *RankList) WithRankTracking(opts RankTracking) *RankList {
+	cloned := this.Clone()
+	if opts.StreamMaxLen <= 0 {
+		opts.StreamMaxLen = defaultRankEventsMaxLen
+	}
+	cloned.tracking = &opts
+	return cloned
+}
+
+func (this *RankList) _ranksKey() string {
+	return this.key + ":ranks"
+}
+
+func (this *RankList) _trackedSetArgs(m redis.Z, maxMembers int, flags string) ([]string, []interface{}) {
+	opts := this.tracking
+	stream := opts.Stream
+	useStream := 1
+	if stream == "" {
+		stream = this.key
+		useStream = 0
+	}
+	keys := []string{this.key, stream, this._ranksKey()}
+	if this.withData {
+		keys = append(keys, this._dataKey())
+	}
+	args := make([]interface{}, 0, 8+len(opts.Thresholds))
+	args = append(args, this._desc(), maxMembers, m.Member, m.Score, opts.Channel, useStream, opts.StreamMaxLen, flags)
+	for _, t := range opts.Thresholds {
+		args = append(args, t)
+	}
+	return keys, args
+}
+
+func (this *RankList) _trackedSet(m redis.Z, maxMembers int) (int64, []string, error) {
+	c := context.TODO()
+	keys, args := this._trackedSetArgs(m, maxMembers, "")
+	rs, err := luaRankListTrackedSet.Run(c, this.redis, keys, args...).Slice()
+	if err != nil {
+		return 0, nil, err
+	}
+	added, evicted := parseZAddAndTrim(rs)
+	return added, evicted, nil
+}
+
+// GetRankDelta returns the current, the previous and the best ranking of member.
+func (this *RankList) GetRankDelta(member string) (RankDelta, error) {
+	var delta RankDelta
+	key := this.key
+	c := context.TODO()
+	var rankCmd *redis.IntCmd
+	var stateCmd *redis.StringCmd
+	_, err := this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		if this.Order == OrderingDesc {
+			rankCmd = pipe.ZRevRank(c, key, member)
+		} else {
+			rankCmd = pipe.ZRank(c, key, member)
+		}
+		stateCmd = pipe.HGet(c, this._ranksKey(), member)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return delta, err
+	}
+	if rankCmd.Err() == nil {
+		delta.Rank = rankCmd.Val() + 1
+	}
+	if prev, best, ok := strings.Cut(stateCmd.Val(), ":"); ok {
+		delta.Prev, _ = strconv.ParseInt(prev, 10, 64)
+		delta.Best, _ = strconv.ParseInt(best, 10, 64)
+	}
+	if delta.Rank > 0 && delta.Prev > 0 {
+		delta.Delta = delta.Prev - delta.Rank
+	}
+	return delta, nil
+}
@@ -0,0 +1,170 @@
+package redisobj
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TimeSeriesResolution is a bucket size with how long its buckets are kept.
+type TimeSeriesResolution struct {
+	Step      time.Duration
+	Retention time.Duration
+}
+
+var (
+	TimeSeriesMinute = TimeSeriesResolution{Step: time.Minute, Retention: 48 * time.Hour}
+	TimeSeriesHour   = TimeSeriesResolution{Step: time.Hour, Retention: 60 * 24 * time.Hour}
+	TimeSeriesDay    = TimeSeriesResolution{Step: 24 * time.Hour, Retention: 2 * 365 * 24 * time.Hour}
+)
+
+type TimeSeriesPoint struct {
+	Time  time.Time
+	Value int64
+}
+
+// TimeSeriesCounter counts events into time buckets, one key per bucket:
+// "key:<step seconds>:<bucket unix time>", which expires after the retention of its resolution.
+// Every increment is rolled up into all resolutions at write time.
+// Buckets are aligned to unix time, so day buckets start at 00:00 UTC.
+type TimeSeriesCounter struct {
+	core
+	resolutions []TimeSeriesResolution
+}
+
+// NewTimeSeriesCounter creates a counter with minute, hour and day resolutions if none given.
+func NewTimeSeriesCounter(redis *redis.Client, key string, resolutions ...TimeSeriesResolution) *TimeSeriesCounter {
+	if len(resolutions) <= 0 {
+		resolutions = []TimeSeriesResolution{TimeSeriesMinute, TimeSeriesHour, TimeSeriesDay}
+	}
+	for _, r := range resolutions {
+		if r.Step < time.Second || r.Step%time.Second != 0 {
+			panic(fmt.Errorf("invalid resolution step: %s", r.Step))
+		}
+	}
+	return &TimeSeriesCounter{
+		core:        *newCore(redis, key),
+		resolutions: resolutions,
+	}
+}
+
+func (this *TimeSeriesCounter) bucketOf(ts time.Time, step time.Duration) int64 {
+	s := int64(step / time.Second)
+	unix := ts.Unix()
+	bucket := unix / s * s
+	if unix < 0 && unix%s != 0 {
+		bucket -= s
+	}
+	return bucket
+}
+
+func (this *TimeSeriesCounter) bucketKey(step time.Duration, bucket int64) string {
+	return this.buildKey(strconv.FormatInt(int64(step/time.Second), 10), strconv.FormatInt(bucket, 10))
+}
+
+func (this *TimeSeriesCounter) Inc(ts time.Time) error {
+	return this.IncBy(ts, 1)
+}
+
+// IncBy increments the buckets containing ts in every resolution.
+func (this *TimeSeriesCounter) IncBy(ts time.Time, inc int64) error {
+	c := context.TODO()
+	_, err := this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for _, r := range this.resolutions {
+			bucket := this.bucketOf(ts, r.Step)
+			key := this.bucketKey(r.Step, bucket)
+			expiredAt := time.Unix(bucket, 0).Add(r.Step + r.Retention)
+			pipe.IncrBy(c, key, inc)
+			pipe.ExpireAt(c, key, expiredAt)
+		}
+		return nil
+	})
+	return err
+}
+
+// pick the coarsest resolution which divides step
+func (this *TimeSeriesCounter) resolutionFor(step time.Duration) (TimeSeriesResolution, error) {
+	var found TimeSeriesResolution
+	for _, r := range this.resolutions {
+		if step%r.Step == 0 && r.Step > found.Step {
+			found = r
+		}
+	}
+	if found.Step <= 0 {
+		return found, fmt.Errorf("unsupported step: %s", step)
+	}
+	return found, nil
+}
+
+// Get returns the count of the bucket with size step containing ts.
+func (this *TimeSeriesCounter) Get(ts time.Time, step time.Duration) (int64, error) {
+	points, err := this.Range(ts, ts, step)
+	if err != nil {
+		return 0, err
+	}
+	return points[0].Value, nil
+}
+
+// Range returns one point per step from the bucket containing from to the bucket containing to,
+// missing buckets are filled with 0.
+// The step must be a multiple of a configured resolution, e.g. 5m or 6h. Larger steps are summed
+// up from the finer buckets on read.
+func (this *TimeSeriesCounter) Range(from, to time.Time, step time.Duration) ([]TimeSeriesPoint, error) {
+	if step < time.Second || step%time.Second != 0 {
+		return nil, fmt.Errorf("invalid step: %s", step)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid params: from(%s) > to(%s)", from, to)
+	}
+	r, err := this.resolutionFor(step)
+	if err != nil {
+		return nil, err
+	}
+
+	first := this.bucketOf(from, step)
+	last := this.bucketOf(to, step)
+	stepSec := int64(step / time.Second)
+	subSec := int64(r.Step / time.Second)
+	subCount := stepSec / subSec
+
+	points := make([]TimeSeriesPoint, 0, (last-first)/stepSec+1)
+	keys := make([]string, 0, cap(points)*int(subCount))
+	for b := first; b <= last; b += stepSec {
+		points = append(points, TimeSeriesPoint{Time: time.Unix(b, 0)})
+		for sub := b; sub < b+stepSec; sub += subSec {
+			keys = append(keys, this.bucketKey(r.Step, sub))
+		}
+	}
+
+	c := context.TODO()
+	const batchSize = 1000
+	cmds := make([]*redis.SliceCmd, 0, len(keys)/batchSize+1)
+	_, err = this.redis.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for i := 0; i < len(keys); i += batchSize {
+			end := min(i+batchSize, len(keys))
+			cmds = append(cmds, pipe.MGet(c, keys[i:end]...))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	i := 0
+	for _, cmd := range cmds {
+		for _, v := range cmd.Val() {
+			if s, ok := v.(string); ok {
+				n, err := strconv.ParseInt(s, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid bucket value: %s=%s", keys[i], s)
+				}
+				points[i/int(subCount)].Value += n
+			}
+			i++
+		}
+	}
+	return points, nil
+}
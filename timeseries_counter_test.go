@@ -0,0 +1,59 @@
+package redisobj
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeSeriesCounter(t *testing.T) {
+	key := "prefiex_test_TimeSeriesCounter"
+	counter := NewTimeSeriesCounter(newTestClient(), key)
+	c := context.TODO()
+	cleanup := func() {
+		keys, _ := counter.redis.Keys(c, key+":*").Result()
+		if len(keys) > 0 {
+			counter.redis.Del(c, keys...)
+		}
+	}
+	cleanup()
+	t.Cleanup(cleanup)
+
+	base := time.Now().Truncate(time.Hour)
+	assert.NoError(t, counter.Inc(base.Add(time.Minute)))
+	assert.NoError(t, counter.IncBy(base.Add(2*time.Minute), 2))
+	assert.NoError(t, counter.IncBy(base.Add(2*time.Minute+30*time.Second), 3))
+	assert.NoError(t, counter.Inc(base.Add(7*time.Minute)))
+
+	points, err := counter.Range(base, base.Add(4*time.Minute), time.Minute)
+	assert.NoError(t, err)
+	values := make([]int64, 0, len(points))
+	for _, p := range points {
+		values = append(values, p.Value)
+	}
+	assert.Equal(t, []int64{0, 1, 5, 0, 0}, values)
+	assert.True(t, points[0].Time.Equal(base))
+
+	// summed up from the minute buckets on read
+	points, err = counter.Range(base, base.Add(9*time.Minute), 5*time.Minute)
+	assert.NoError(t, err)
+	if assert.Equal(t, 2, len(points)) {
+		assert.Equal(t, int64(6), points[0].Value)
+		assert.Equal(t, int64(1), points[1].Value)
+	}
+
+	// rolled up at write time
+	n, err := counter.Get(base.Add(30*time.Minute), time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+	ttl, err := counter.redis.TTL(c, counter.bucketKey(time.Minute, base.Unix()+60)).Result()
+	assert.NoError(t, err)
+	assert.Greater(t, ttl, time.Duration(0))
+
+	_, err = counter.Range(base, base, 90*time.Second)
+	assert.Error(t, err)
+	_, err = counter.Range(base, base.Add(-time.Minute), time.Minute)
+	assert.Error(t, err)
+}
@@ -3,21 +3,214 @@ package redisobj
 import (
 	"context"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/cupen/redisobj/orderings"
 	"github.com/redis/go-redis/v9"
 )
 
 const (
-	OrderingDesc = 1
-	OrderingAsc  = 2
+	OrderingDesc = orderings.Desc
+	OrderingAsc  = orderings.Asc
 )
 
+// hdel_all(key, fields) removes the fields in chunks to keep unpack() within the lua stack limit
+const luaHDelAllFunc = `
+local function hdel_all(key, fields)
+	for i = 1, #fields, 1000 do
+		redis.call("HDEL", key, unpack(fields, i, math.min(i + 999, #fields)))
+	end
+end
+`
+
+// trim(key, max, desc, companions) trims the zset to max members (no limit if <= 0), the lowest ranked
+// are evicted and removed from the companion hashes. Returns the evicted members.
+const luaTrimFunc = luaHDelAllFunc + `
+local function trim(key, max, desc, companions)
+	local card = redis.call("ZCARD", key)
+	if max <= 0 or card <= max then
+		return {}
+	end
+	local excess = card - max
+	local evicted
+	if desc then
+		evicted = redis.call("ZRANGE", key, 0, excess - 1)
+		redis.call("ZREMRANGEBYRANK", key, 0, excess - 1)
+	else
+		evicted = redis.call("ZRANGE", key, -excess, -1)
+		redis.call("ZREMRANGEBYRANK", key, -excess, -1)
+	end
+	for _, hash in ipairs(companions) do
+		hdel_all(hash, evicted)
+	end
+	return evicted
+end
+`
+
+// add members and trim the zset to max members, KEYS: zset, [companion hashes of members...]
+// ARGV: max, desc(1/0), score1, member1, ...
+// returns {added, evicted members}
+var luaZAddAndTrim = redis.NewScript(luaTrimFunc + `
+local added = 0
+for i = 3, #ARGV, 2 do
+	added = added + redis.call("ZADD", KEYS[1], ARGV[i], ARGV[i + 1])
+end
+local evicted = trim(KEYS[1], tonumber(ARGV[1]), ARGV[2] == "1", {unpack(KEYS, 2)})
+return {added, evicted}
+`)
+
+func zaddAndTrimArgs(maxMembers int, ordering orderings.Ordering, elems []redis.Z) []interface{} {
+	desc := 0
+	if ordering == OrderingDesc {
+		desc = 1
+	}
+	args := make([]interface{}, 0, 2+len(elems)*2)
+	args = append(args, maxMembers, desc)
+	for _, elem := range elems {
+		args = append(args, elem.Score, elem.Member)
+	}
+	return args
+}
+
+func parseZAddAndTrim(rs []interface{}) (int64, []string) {
+	added, _ := rs[0].(int64)
+	_evicted, _ := rs[1].([]interface{})
+	evicted := make([]string, 0, len(_evicted))
+	for _, m := range _evicted {
+		evicted = append(evicted, m.(string))
+	}
+	return added, evicted
+}
+
+// keys: zset, [companion hashes of members...]
+func zaddAndTrim(rds *redis.Client, keys []string, maxMembers int, ordering orderings.Ordering, elems []redis.Z) (int64, []string, error) {
+	c := context.TODO()
+	args := zaddAndTrimArgs(maxMembers, ordering, elems)
+	rs, err := luaZAddAndTrim.Run(c, rds, keys, args...).Slice()
+	if err != nil {
+		return 0, nil, err
+	}
+	added, evicted := parseZAddAndTrim(rs)
+	return added, evicted, nil
+}
+
+// add one member with the ZADD flags and trim the zset to max members, KEYS: as luaZAddAndTrim
+// ARGV: max, desc(1/0), flags separated by spaces (e.g. "GT CH", "INCR"), score, member
+// returns {the reply of ZADD, evicted members}
+var luaZAddFlagsAndTrim = redis.NewScript(luaTrimFunc + `
+local args = {KEYS[1]}
+for flag in string.gmatch(ARGV[3], "%S+") do
+	args[#args + 1] = flag
+end
+args[#args + 1] = ARGV[4]
+args[#args + 1] = ARGV[5]
+local rs = redis.call("ZADD", unpack(args))
+local evicted = trim(KEYS[1], tonumber(ARGV[1]), ARGV[2] == "1", {unpack(KEYS, 2)})
+return {rs, evicted}
+`)
+
+// zaddFlags returns the flags of args for luaZAddFlagsAndTrim, the members are ignored.
+func zaddFlags(args redis.ZAddArgs) string {
+	flags := make([]string, 0, 3)
+	if args.NX {
+		flags = append(flags, "NX")
+	}
+	if args.XX {
+		flags = append(flags, "XX")
+	}
+	if args.GT {
+		flags = append(flags, "GT")
+	}
+	if args.LT {
+		flags = append(flags, "LT")
+	}
+	if args.Ch {
+		flags = append(flags, "CH")
+	}
+	return strings.Join(flags, " ")
+}
+
+func zaddFlagsAndTrimArgs(maxMembers int, ordering orderings.Ordering, flags string, m redis.Z) []interface{} {
+	desc := 0
+	if ordering == OrderingDesc {
+		desc = 1
+	}
+	return []interface{}{maxMembers, desc, flags, m.Score, m.Member}
+}
+
+// parseZIncrAndTrim parses the reply of luaZAddFlagsAndTrim with "INCR", redis.Nil if not written.
+func parseZIncrAndTrim(rs []interface{}) (float64, error) {
+	s, ok := rs[0].(string)
+	if !ok {
+		return 0, redis.Nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// ScoreBound is a score range bound, e.g. "1", "(1", "-inf" or "+inf".
+type ScoreBound string
+
+// LexBound is a lexicographic range bound, e.g. "[a", "(a", "-" or "+".
+type LexBound string
+
+const (
+	ScoreNegInf ScoreBound = "-inf"
+	ScorePosInf ScoreBound = "+inf"
+
+	LexMin LexBound = "-"
+	LexMax LexBound = "+"
+)
+
+// ScoreIncl is an inclusive score bound.
+func ScoreIncl(score float64) ScoreBound {
+	if math.IsInf(score, 1) {
+		return ScorePosInf
+	}
+	if math.IsInf(score, -1) {
+		return ScoreNegInf
+	}
+	return ScoreBound(strconv.FormatFloat(score, 'f', -1, 64))
+}
+
+// ScoreExcl is an exclusive score bound.
+func ScoreExcl(score float64) ScoreBound {
+	return "(" + ScoreIncl(score)
+}
+
+// LexIncl is an inclusive lexicographic bound.
+func LexIncl(s string) LexBound {
+	return LexBound("[" + s)
+}
+
+// LexExcl is an exclusive lexicographic bound.
+func LexExcl(s string) LexBound {
+	return LexBound("(" + s)
+}
+
+// LexPrefix returns the bounds matching all members starting with prefix.
+func LexPrefix(prefix string) (LexBound, LexBound) {
+	if prefix == "" {
+		return LexMin, LexMax
+	}
+	// the upper bound is the prefix with its last byte incremented, the trailing 0xff bytes carry
+	end := []byte(prefix)
+	for len(end) > 0 && end[len(end)-1] == 0xff {
+		end = end[:len(end)-1]
+	}
+	if len(end) == 0 {
+		return LexIncl(prefix), LexMax
+	}
+	end[len(end)-1]++
+	return LexIncl(prefix), LexExcl(string(end))
+}
+
 type ZSet struct {
 	redis      *redis.Client
 	key        string
-	ordering   int
+	ordering   orderings.Ordering
 	maxMembers int
 }
 
@@ -29,25 +222,33 @@ func NewZSet(c *redis.Client, key string) *ZSet {
 	}
 }
 
-func (this *ZSet) SetOrdering(ordering int) {
+// SetMaxMembers enables the capacity mode: every write trims the zset to maxMembers atomically,
+// the lowest ranked members are evicted. maxMembers <= 0 means unlimited.
+func (this *ZSet) SetMaxMembers(maxMembers int) {
+	this.maxMembers = maxMembers
+}
+
+func (this *ZSet) GetMaxMembers() int {
+	return this.maxMembers
+}
+
+func (this *ZSet) SetOrdering(ordering orderings.Ordering) {
 	this.ordering = ordering
 }
 
-func (this *ZSet) GetOrdering() int {
+func (this *ZSet) GetOrdering() orderings.Ordering {
 	return this.ordering
 }
 
 func (this *ZSet) Set(member string, score float64) error {
-	c := context.TODO()
 	elem := redis.Z{Member: member, Score: score}
-	_, err := this.redis.ZAdd(c, this.key, elem).Result()
-	if err != nil {
+	if this.maxMembers > 0 {
+		_, err := this.AddWithLimit(elem)
 		return err
 	}
-	// if this.maxMembers > 0 {
-	// 	_, _ = this.LimitIf(this.maxMembers)
-	// }
-	return nil
+	c := context.TODO()
+	_, err := this.redis.ZAdd(c, this.key, elem).Result()
+	return err
 }
 
 func (this *ZSet) Add(member string, score float64) error {
@@ -55,15 +256,72 @@ func (this *ZSet) Add(member string, score float64) error {
 }
 
 func (this *ZSet) AddBatch(elems ...redis.Z) error {
+	if this.maxMembers > 0 {
+		_, err := this.AddWithLimit(elems...)
+		return err
+	}
 	c := context.TODO()
 	_, err := this.redis.ZAdd(c, this.key, elems...).Result()
-	if err != nil {
-		return err
+	return err
+}
+
+// AddWithLimit adds the members and trims the zset to maxMembers in one script, returns the evicted members.
+func (this *ZSet) AddWithLimit(elems ...redis.Z) ([]string, error) {
+	_, evicted, err := zaddAndTrim(this.redis, []string{this.key}, this.maxMembers, this.ordering, elems)
+	return evicted, err
+}
+
+// IncrScore atomically adds delta to the score of member and returns the new score.
+func (this *ZSet) IncrScore(member string, delta float64) (float64, error) {
+	c := context.TODO()
+	if this.maxMembers > 0 {
+		m := redis.Z{Member: member, Score: delta}
+		args := zaddFlagsAndTrimArgs(this.maxMembers, this.ordering, "INCR", m)
+		rs, err := luaZAddFlagsAndTrim.Run(c, this.redis, []string{this.key}, args...).Slice()
+		if err != nil {
+			return 0, err
+		}
+		return parseZIncrAndTrim(rs)
 	}
-	// if this.maxMembers > 0 {
-	// 	_, _ = this.LimitIf(this.maxMembers)
-	// }
-	return nil
+	return this.redis.ZIncrBy(c, this.key, delta, member).Result()
+}
+
+func (this *ZSet) addArgs(args redis.ZAddArgs, member string, score float64) (bool, error) {
+	c := context.TODO()
+	args.Ch = true
+	m := redis.Z{Member: member, Score: score}
+	if this.maxMembers > 0 {
+		argv := zaddFlagsAndTrimArgs(this.maxMembers, this.ordering, zaddFlags(args), m)
+		rs, err := luaZAddFlagsAndTrim.Run(c, this.redis, []string{this.key}, argv...).Slice()
+		if err != nil {
+			return false, err
+		}
+		changed, _ := parseZAddAndTrim(rs)
+		return changed > 0, nil
+	}
+	args.Members = []redis.Z{m}
+	count, err := this.redis.ZAddArgs(c, this.key, args).Result()
+	return count > 0, err
+}
+
+// SetIfGreater sets the score only if it is greater than the current one or the member is absent.
+func (this *ZSet) SetIfGreater(member string, score float64) (bool, error) {
+	return this.addArgs(redis.ZAddArgs{GT: true}, member, score)
+}
+
+// SetIfLess sets the score only if it is less than the current one or the member is absent.
+func (this *ZSet) SetIfLess(member string, score float64) (bool, error) {
+	return this.addArgs(redis.ZAddArgs{LT: true}, member, score)
+}
+
+// AddIfAbsent adds the member only if it does not exist.
+func (this *ZSet) AddIfAbsent(member string, score float64) (bool, error) {
+	return this.addArgs(redis.ZAddArgs{NX: true}, member, score)
+}
+
+// UpdateOnly updates the score only if the member exists.
+func (this *ZSet) UpdateOnly(member string, score float64) (bool, error) {
+	return this.addArgs(redis.ZAddArgs{XX: true}, member, score)
 }
 
 func (this *ZSet) Delete(member string) error {
@@ -114,7 +372,7 @@ func (this *ZSet) Clear() error {
 	return nil
 }
 
-func (this *ZSet) GetListByOrder(start int, count int, ordering int) ([]redis.Z, error) {
+func (this *ZSet) GetListByOrder(start int, count int, ordering orderings.Ordering) ([]redis.Z, error) {
 	c := context.TODO()
 	end := start + count - 1
 	if start > end {
@@ -129,11 +387,80 @@ func (this *ZSet) GetListByOrder(start int, count int, ordering int) ([]redis.Z,
 	return list, err
 }
 
-func (this *ZSet) GetList(start int, count int) ([]redis.Z, error) {
+// GetList returns count members from start, the ordering defaults to the object's ordering.
+func (this *ZSet) GetList(start int, count int, ordering ...orderings.Ordering) ([]redis.Z, error) {
 	if count <= 0 {
 		return nil, nil
 	}
-	return this.GetListByOrder(start, count, this.ordering)
+	return this.GetListByOrder(start, count, this.ordering.Or(ordering...))
+}
+
+func (this *ZSet) rangeArgs(min, max string, offset, limit int, ordering []orderings.Ordering) redis.ZRangeArgs {
+	args := redis.ZRangeArgs{
+		Key:    this.key,
+		Start:  min,
+		Stop:   max,
+		Rev:    this.ordering.Or(ordering...) == OrderingDesc,
+		Offset: int64(offset),
+		Count:  int64(limit),
+	}
+	if limit <= 0 && offset > 0 {
+		args.Count = -1
+	}
+	return args
+}
+
+// RangeByScore returns the members with min <= score <= max, skipping offset members and returning at most limit
+// members (limit <= 0 means no limit). The ordering defaults to the object's ordering.
+func (this *ZSet) RangeByScore(min, max ScoreBound, offset, limit int, ordering ...orderings.Ordering) ([]redis.Z, error) {
+	c := context.TODO()
+	args := this.rangeArgs(string(min), string(max), offset, limit, ordering)
+	args.ByScore = true
+	list, err := this.redis.ZRangeArgsWithScores(c, args).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return list, err
+}
+
+func (this *ZSet) CountByScore(min, max ScoreBound) (int64, error) {
+	c := context.TODO()
+	count, err := this.redis.ZCount(c, this.key, string(min), string(max)).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+func (this *ZSet) DeleteByScore(min, max ScoreBound) (int64, error) {
+	c := context.TODO()
+	count, err := this.redis.ZRemRangeByScore(c, this.key, string(min), string(max)).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
+}
+
+// RangeByLex returns the members between min and max, all members must have the same score.
+// The ordering defaults to the object's ordering.
+func (this *ZSet) RangeByLex(min, max LexBound, offset, limit int, ordering ...orderings.Ordering) ([]string, error) {
+	c := context.TODO()
+	args := this.rangeArgs(string(min), string(max), offset, limit, ordering)
+	args.ByLex = true
+	list, err := this.redis.ZRangeArgs(c, args).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return list, err
+}
+
+func (this *ZSet) CountByLex(min, max LexBound) (int64, error) {
+	c := context.TODO()
+	count, err := this.redis.ZLexCount(c, this.key, string(min), string(max)).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return count, err
 }
 
 func (this *ZSet) GetScore(member string) (float64, error) {
@@ -170,11 +497,12 @@ func (this *ZSet) LimitIf(maxMembers int) (int64, error) {
 		// unlimit
 		return 0, nil
 	}
-	return this.DelByRanking(maxMembers+1, 3)
+	_, evicted, err := zaddAndTrim(this.redis, []string{this.key}, maxMembers, this.ordering, nil)
+	return int64(len(evicted)), err
 }
 
-func (this *ZSet) GetTop(count int) ([]redis.Z, error) {
-	return this.GetList(0, count)
+func (this *ZSet) GetTop(count int, ordering ...orderings.Ordering) ([]redis.Z, error) {
+	return this.GetList(0, count, ordering...)
 }
 
 func (this *ZSet) SetTTL(ttl time.Duration) error {
@@ -0,0 +1,159 @@
+package redisobj
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PopMin removes and returns up to n members with the lowest scores.
+func (this *ZSet) PopMin(n int) ([]redis.Z, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	c := context.TODO()
+	items, err := this.redis.ZPopMin(c, this.key, int64(n)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return items, err
+}
+
+// PopMax removes and returns up to n members with the highest scores.
+func (this *ZSet) PopMax(n int) ([]redis.Z, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	c := context.TODO()
+	items, err := this.redis.ZPopMax(c, this.key, int64(n)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return items, err
+}
+
+// BPopMin blocks until a member can be popped from this or others, checked in order, and returns
+// the member with the lowest score and the key it was popped from. Returns nil on timeout,
+// timeout <= 0 blocks forever.
+func (this *ZSet) BPopMin(timeout time.Duration, others ...*ZSet) (*redis.ZWithKey, error) {
+	return bzpop(context.TODO(), this.redis, false, timeout, zsetKeys(this, others))
+}
+
+// BPopMax is like BPopMin but pops the member with the highest score.
+func (this *ZSet) BPopMax(timeout time.Duration, others ...*ZSet) (*redis.ZWithKey, error) {
+	return bzpop(context.TODO(), this.redis, true, timeout, zsetKeys(this, others))
+}
+
+func bzpop(c context.Context, rds *redis.Client, popMax bool, timeout time.Duration, keys []string) (*redis.ZWithKey, error) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	var cmd *redis.ZWithKeyCmd
+	if popMax {
+		cmd = rds.BZPopMax(c, timeout, keys...)
+	} else {
+		cmd = rds.BZPopMin(c, timeout, keys...)
+	}
+	rs, err := cmd.Result()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return rs, nil
+}
+
+// ZSetConsumer pops members from zsets as a priority queue and hands them to a pool of workers.
+type ZSetConsumer struct {
+	redis   *redis.Client
+	keys    []string
+	workers int
+	max     bool
+	poll    time.Duration
+	onError func(error)
+}
+
+// NewZSetConsumer pops the lowest scores first from zsets, checked in order.
+func NewZSetConsumer(workers int, zset *ZSet, others ...*ZSet) *ZSetConsumer {
+	if workers <= 0 {
+		panic(fmt.Errorf("invalid workers: %d", workers))
+	}
+	return &ZSetConsumer{
+		redis:   zset.redis,
+		keys:    zsetKeys(zset, others),
+		workers: workers,
+		poll:    time.Second,
+	}
+}
+
+// WithPopMax pops the highest scores first.
+func (this *ZSetConsumer) WithPopMax() *ZSetConsumer {
+	this.max = true
+	return this
+}
+
+// WithPollTimeout sets how long a pop blocks, which bounds how long Run takes to notice the shutdown.
+// The blocking pops wait in seconds, so poll must be at least 1s.
+func (this *ZSetConsumer) WithPollTimeout(poll time.Duration) *ZSetConsumer {
+	if poll < time.Second {
+		panic(fmt.Errorf("invalid poll timeout: %s", poll))
+	}
+	this.poll = poll
+	return this
+}
+
+// WithOnError sets a hook called with the pop errors, Run keeps going after a pause of the poll timeout.
+func (this *ZSetConsumer) WithOnError(hook func(error)) *ZSetConsumer {
+	this.onError = hook
+	return this
+}
+
+// Run pops members and calls handler in the workers until ctx is done, then waits for the running
+// handlers and returns ctx.Err(). A member popped but not handed to a worker at shutdown is added back,
+// unless it was added again meanwhile.
+func (this *ZSetConsumer) Run(ctx context.Context, handler func(ctx context.Context, item redis.ZWithKey)) error {
+	items := make(chan redis.ZWithKey)
+	var wg sync.WaitGroup
+	for i := 0; i < this.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				handler(ctx, item)
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(items)
+
+	for ctx.Err() == nil {
+		// not canceled by ctx, so that a member is never popped by a canceled command and lost
+		item, err := bzpop(context.Background(), this.redis, this.max, this.poll, this.keys)
+		if err != nil {
+			if this.onError != nil {
+				this.onError(err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(this.poll):
+			}
+			continue
+		}
+		if item == nil {
+			continue
+		}
+		select {
+		case items <- *item:
+		case <-ctx.Done():
+			c := context.Background()
+			if err := this.redis.ZAddNX(c, item.Key, item.Z).Err(); err != nil && this.onError != nil {
+				this.onError(err)
+			}
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,231 @@
+package redisobj
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestZSet(t *testing.T, key string) *ZSet {
+	obj := NewZSet(newTestClient(), key)
+	obj.Clear()
+	t.Cleanup(func() {
+		obj.Clear()
+	})
+	return obj
+}
+
+func TestZSet_SetMaxMembers(t *testing.T) {
+	zset := newTestZSet(t, "prefiex_test_zset_SetMaxMembers")
+	zset.SetMaxMembers(3)
+
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, zset.Set(fmt.Sprintf("id%d", i), float64(i)))
+	}
+	ok, err := zset.SetIfGreater("id4", 4)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = zset.AddIfAbsent("id5", 5)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = zset.UpdateOnly("id1", 10)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	score, err := zset.IncrScore("id6", 6)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(6), score)
+
+	size, err := zset.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), size)
+	items, err := zset.GetTop(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id6", "id5", "id4"}, zsetMembers(items))
+}
+
+func TestZSet_PopMin(t *testing.T) {
+	zset := newTestZSet(t, "prefiex_test_zset_PopMin")
+	for i := 1; i <= 5; i++ {
+		zset.Set(fmt.Sprintf("id%d", i), float64(i))
+	}
+
+	items, err := zset.PopMin(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id1", "id2"}, zsetMembers(items))
+	items, err = zset.PopMax(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id5", "id4"}, zsetMembers(items))
+	items, err = zset.PopMin(0)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+
+	size, err := zset.Size()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), size)
+}
+
+func TestZSet_BPopMin(t *testing.T) {
+	zset := newTestZSet(t, "prefiex_test_zset_BPopMin")
+	other := newTestZSet(t, "prefiex_test_zset_BPopMin_other")
+	other.Set("id1", 1)
+	other.Set("id2", 2)
+
+	item, err := zset.BPopMin(time.Second, other)
+	assert.NoError(t, err)
+	if assert.NotNil(t, item) {
+		assert.Equal(t, other.key, item.Key)
+		assert.Equal(t, "id1", item.Member)
+	}
+	item, err = zset.BPopMax(time.Second, other)
+	assert.NoError(t, err)
+	if assert.NotNil(t, item) {
+		assert.Equal(t, "id2", item.Member)
+	}
+
+	item, err = zset.BPopMin(time.Second, other)
+	assert.NoError(t, err)
+	assert.Nil(t, item)
+}
+
+func TestZSetConsumer_Run(t *testing.T) {
+	zset := newTestZSet(t, "prefiex_test_zset_Consumer")
+	for i := 1; i <= 10; i++ {
+		zset.Set(fmt.Sprintf("id%d", i), float64(i))
+	}
+
+	var mu sync.Mutex
+	handled := make([]string, 0, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewZSetConsumer(3, zset).Run(ctx, func(ctx context.Context, item redis.ZWithKey) {
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, item.Member.(string))
+		})
+	}()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled) == 10
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	assert.ElementsMatch(t, []string{"id1", "id2", "id3", "id4", "id5", "id6", "id7", "id8", "id9", "id10"}, handled)
+}
+
+func TestZSetConsumer_RunCanceled(t *testing.T) {
+	zset := newTestZSet(t, "prefiex_test_zset_Consumer_canceled")
+	zset.Set("id1", 1)
+	zset.Set("id2", 2)
+
+	started := make(chan string, 1)
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewZSetConsumer(1, zset).Run(ctx, func(ctx context.Context, item redis.ZWithKey) {
+			started <- item.Member.(string)
+			<-release
+		})
+	}()
+	assert.Equal(t, "id1", <-started)
+	// id2 is popped while the only worker is busy
+	assert.Eventually(t, func() bool {
+		size, err := zset.Size()
+		return err == nil && size == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	// the re-add keeps the score written meanwhile
+	zset.Set("id2", 20)
+
+	cancel()
+	close(release)
+	assert.Equal(t, context.Canceled, <-done)
+	items, err := zset.GetTop(10)
+	assert.NoError(t, err)
+	assert.Equal(t, []redis.Z{{Member: "id2", Score: 20}}, items)
+}
+
+func TestZSetWithTTL_GetList(t *testing.T) {
+	zset := NewZSetWithTTL(newTestZSet(t, "prefiex_test_zsetttl_GetList").redis, "prefiex_test_zsetttl_GetList", time.Hour)
+	now := time.Now()
+	for i := 1; i <= 5; i++ {
+		zset.Set(fmt.Sprintf("id%d", i), now.Add(time.Duration(i)*time.Second))
+	}
+
+	items, err := zset.GetList(2, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id3", "id2"}, zsetMembers(items))
+	items, err = zset.GetList(2, 2, OrderingAsc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id3", "id4"}, zsetMembers(items))
+}
+
+func TestZSet_RangeByScore(t *testing.T) {
+	zset := newTestZSet(t, "prefiex_test_zset_RangeByScore")
+	zset.SetOrdering(OrderingAsc)
+	for i := 1; i <= 10; i++ {
+		zset.Set(fmt.Sprintf("id%d", i), float64(i))
+	}
+
+	items, err := zset.RangeByScore(ScoreIncl(3), ScoreExcl(6), 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id3", "id4", "id5"}, zsetMembers(items))
+	items, err = zset.RangeByScore(ScoreIncl(3), ScorePosInf, 1, 2, OrderingDesc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id9", "id8"}, zsetMembers(items))
+	items, err = zset.RangeByScore(ScoreNegInf, ScoreIncl(5), 3, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id4", "id5"}, zsetMembers(items))
+
+	count, err := zset.CountByScore(ScoreExcl(2), ScoreIncl(4))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	deleted, err := zset.DeleteByScore(ScoreNegInf, ScoreExcl(3))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+}
+
+func TestZSet_RangeByLex(t *testing.T) {
+	zset := newTestZSet(t, "prefiex_test_zset_RangeByLex")
+	zset.SetOrdering(OrderingAsc)
+	for _, name := range []string{"apple", "apricot", "banana", "blueberry", "cherry"} {
+		zset.Set(name, 0)
+	}
+
+	from, to := LexPrefix("ap")
+	members, err := zset.RangeByLex(from, to, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"apple", "apricot"}, members)
+	members, err = zset.RangeByLex(LexExcl("apricot"), LexMax, 1, 2, OrderingDesc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"blueberry", "banana"}, members)
+	members, err = zset.RangeByLex(LexMin, LexIncl("banana"), 0, 2, OrderingDesc)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"banana", "apricot"}, members)
+
+	count, err := zset.CountByLex(LexIncl("b"), LexExcl("c"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// the members after prefix+"\xff" still match, and the 0xff bytes carry
+	zset.Set("ap\xff\x01", 0)
+	zset.Set("b\xff\xff", 0)
+	from, to = LexPrefix("ap")
+	members, err = zset.RangeByLex(from, to, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"apple", "apricot", "ap\xff\x01"}, members)
+	from, to = LexPrefix("b\xff")
+	members, err = zset.RangeByLex(from, to, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b\xff\xff"}, members)
+	from, to = LexPrefix("\xff")
+	assert.Equal(t, LexMax, to)
+	from, to = LexPrefix("")
+	assert.Equal(t, []LexBound{LexMin, LexMax}, []LexBound{from, to})
+}
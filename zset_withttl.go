@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/cupen/redisobj/orderings"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -27,11 +28,11 @@ func NewZSetWithTTL(c *redis.Client, key string, ttl time.Duration) *ZSetWithTTL
 	}
 }
 
-func (this *ZSetWithTTL) SetOrdering(ordering int) {
+func (this *ZSetWithTTL) SetOrdering(ordering orderings.Ordering) {
 	this.ordering = ordering
 }
 
-func (this *ZSetWithTTL) GetOrdering() int {
+func (this *ZSetWithTTL) GetOrdering() orderings.Ordering {
 	return this.ordering
 }
 
@@ -74,7 +75,7 @@ func (this *ZSetWithTTL) Clear() error {
 	return err
 }
 
-func (this *ZSetWithTTL) getListByOrder(start int, end int, ordering int) ([]redis.Z, error) {
+func (this *ZSetWithTTL) getListByOrder(start int, end int, ordering orderings.Ordering) ([]redis.Z, error) {
 	c := context.TODO()
 	if ordering == OrderingDesc {
 		list, err := this.redis.ZRevRangeWithScores(c, this.key, int64(start), int64(end)).Result()
@@ -84,7 +85,8 @@ func (this *ZSetWithTTL) getListByOrder(start int, end int, ordering int) ([]red
 	return list, err
 }
 
-func (this *ZSetWithTTL) GetList(start int, count int) ([]redis.Z, error) {
+// GetList returns count members from start, the ordering defaults to the object's ordering.
+func (this *ZSetWithTTL) GetList(start int, count int, ordering ...orderings.Ordering) ([]redis.Z, error) {
 	if count <= 0 {
 		return nil, nil
 	}
@@ -93,7 +95,7 @@ func (this *ZSetWithTTL) GetList(start int, count int) ([]redis.Z, error) {
 		return nil, fmt.Errorf("Invalid params start(%d) > end(%d)", start, end)
 	}
 
-	return this.getListByOrder(start, count, this.ordering)
+	return this.getListByOrder(start, end, this.ordering.Or(ordering...))
 }
 
 func (this *ZSetWithTTL) GetScore(member string) (int64, error) {
@@ -114,8 +116,8 @@ func (this *ZSetWithTTL) LimitIf(maxMembers int) (int64, error) {
 	return this.DelByRanking(maxMembers+1, 3)
 }
 
-func (this *ZSetWithTTL) GetTop(count int, now time.Time) ([]redis.Z, error) {
-	items, err := this.GetList(0, count)
+func (this *ZSetWithTTL) GetTop(count int, now time.Time, ordering ...orderings.Ordering) ([]redis.Z, error) {
+	items, err := this.GetList(0, count, ordering...)
 	if err != nil {
 		if err == redis.Nil {
 			return nil, nil